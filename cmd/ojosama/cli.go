@@ -5,24 +5,79 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jiro4989/ojosama"
 )
 
 type CmdArgs struct {
-	Text        string
-	OutFile     string
-	Version     bool
-	CharCode    string
-	Completions string
-	Args        []string
+	Text         string
+	OutFile      string
+	Version      bool
+	CharCode     string
+	Completions  string
+	Seed         int64
+	UseSeed      bool
+	Rules        string
+	ReplaceRules bool
+	UserDict     string
+	Level        string
+	MarkStyle    string
+	MarkWeights  string
+	KutenWeights string
+	EndingMemory int
+	MaxInputSize int
+	WavyLine     string
+	WavyCount    string
+	MarkCount    string
+	ScaleNote    bool
+
+	DisableKutenToExclamation bool
+	DisableHonorificPrefix    bool
+	DisableLongNote           bool
+	DisablePronoun            bool
+	DisableDemonstrative      bool
+	DisableSentenceEnding     bool
+	DisableVulgar             bool
+	DisableEndingVariation    bool
+	DisableRules              string
+	DisableCategories         string
+	Args                      []string
 }
 
 const (
-	helpMsgHelp        = "print help"
-	helpMsgText        = "input text"
-	helpMsgOutFile     = "output file"
-	helpMsgCharCode    = "input text file encoding. default is utf8. (utf8, sjis)"
-	helpMsgVersion     = "print version"
-	helpMsgCompletions = "print completions file. (bash, zsh)"
+	helpMsgHelp         = "print help"
+	helpMsgText         = "input text"
+	helpMsgOutFile      = "output file"
+	helpMsgCharCode     = "input text file encoding. default is utf8. (utf8, sjis)"
+	helpMsgVersion      = "print version"
+	helpMsgCompletions  = "print completions file. (bash, zsh)"
+	helpMsgSeed         = "random seed. the same seed always produces the same output"
+	helpMsgRules        = "conversion rule file (yaml, json). rules extend the built-in rules"
+	helpMsgReplaceRules = "replace the built-in rules with the rules of -rules"
+	helpMsgUserDict     = "kagome user dictionary file for tokenizing proper nouns as single words"
+	helpMsgLevel        = "conversion intensity. (subtle, standard, extreme)"
+	helpMsgMarkStyle    = "style of ！ and ？ to add. (random, fullwidth, halfwidth, emoji, double_emoji, input)"
+	helpMsgMarkWeights  = "comma separated weights of ！ and ？ styles for random style. (e.g. fullwidth=3,emoji=1)"
+	helpMsgKutenWeights = "comma separated weights of strings replacing 。. (e.g. 。=2,！=1,❗=1)"
+	helpMsgEndingMemory = "number of recent sentence endings to avoid repeating by rotating alternatives. 0 disables. (e.g. ですわ -> ですのよ)"
+	helpMsgMaxInputSize = "maximum bytes of input text. 0 means unlimited"
+	helpMsgWavyLine     = "wavy line character added before ！ or ？. (e.g. ～, 〜, ー)"
+	helpMsgWavyCount    = "number of wavy lines. N or MIN-MAX. (e.g. 1-3)"
+	helpMsgMarkCount    = "number of ！ or ？ including the input one. N or MIN-MAX. (e.g. 2-4)"
+	helpMsgScaleNote    = "add more wavy lines and marks when the input has repeated ！ or ？"
+
+	helpMsgDisableKutenToExclamation = "disable converting 。 to ！"
+	helpMsgDisableHonorificPrefix    = "disable adding お before nouns"
+	helpMsgDisableLongNote           = "disable adding ～ and ！ before ！ or ？"
+	helpMsgDisablePronoun            = "disable rewriting pronouns. (e.g. 俺 -> 私)"
+	helpMsgDisableDemonstrative      = "disable rewriting demonstratives. (e.g. これ -> こちら)"
+	helpMsgDisableSentenceEnding     = "disable rewriting sentence endings. (e.g. です -> ですわ)"
+	helpMsgDisableVulgar             = "disable rewriting to vulgar words. (e.g. 汚い -> きったねぇ)"
+	helpMsgDisableEndingVariation    = "always use the first phrasing of sentence endings. (e.g. 野球しようぜ -> お野球をいたしませんこと)"
+	helpMsgDisableRules              = "comma separated rule IDs to disable. (e.g. ending.か,pronoun.俺)"
+	helpMsgDisableCategories         = "comma separated rule categories to disable. (e.g. interjection,vulgar)"
 )
 
 func ParseArgs() (*CmdArgs, error) {
@@ -34,9 +89,40 @@ func ParseArgs() (*CmdArgs, error) {
 	flag.StringVar(&opts.CharCode, "charcode", "utf8", helpMsgCharCode)
 	flag.BoolVar(&opts.Version, "v", false, helpMsgVersion)
 	flag.StringVar(&opts.Completions, "completions", "", helpMsgCompletions)
+	flag.Int64Var(&opts.Seed, "seed", 0, helpMsgSeed)
+	flag.StringVar(&opts.Rules, "rules", "", helpMsgRules)
+	flag.BoolVar(&opts.ReplaceRules, "replace-rules", false, helpMsgReplaceRules)
+	flag.StringVar(&opts.UserDict, "userdict", "", helpMsgUserDict)
+	flag.StringVar(&opts.Level, "level", "standard", helpMsgLevel)
+	flag.StringVar(&opts.MarkStyle, "mark-style", "random", helpMsgMarkStyle)
+	flag.StringVar(&opts.MarkWeights, "mark-weights", "", helpMsgMarkWeights)
+	flag.StringVar(&opts.KutenWeights, "kuten-weights", "", helpMsgKutenWeights)
+	flag.IntVar(&opts.EndingMemory, "ending-variety", 0, helpMsgEndingMemory)
+	flag.IntVar(&opts.MaxInputSize, "max-input-size", 0, helpMsgMaxInputSize)
+	flag.StringVar(&opts.WavyLine, "wavy-line", "", helpMsgWavyLine)
+	flag.StringVar(&opts.WavyCount, "wavy-count", "", helpMsgWavyCount)
+	flag.StringVar(&opts.MarkCount, "mark-count", "", helpMsgMarkCount)
+	flag.BoolVar(&opts.ScaleNote, "scale-long-note", false, helpMsgScaleNote)
+	flag.BoolVar(&opts.DisableKutenToExclamation, "disable-kuten-to-exclamation", false, helpMsgDisableKutenToExclamation)
+	flag.BoolVar(&opts.DisableHonorificPrefix, "disable-prefix", false, helpMsgDisableHonorificPrefix)
+	flag.BoolVar(&opts.DisableLongNote, "disable-long-note", false, helpMsgDisableLongNote)
+	flag.BoolVar(&opts.DisablePronoun, "disable-pronoun", false, helpMsgDisablePronoun)
+	flag.BoolVar(&opts.DisableDemonstrative, "disable-demonstrative", false, helpMsgDisableDemonstrative)
+	flag.BoolVar(&opts.DisableSentenceEnding, "disable-ending", false, helpMsgDisableSentenceEnding)
+	flag.BoolVar(&opts.DisableVulgar, "disable-vulgar", false, helpMsgDisableVulgar)
+	flag.BoolVar(&opts.DisableEndingVariation, "disable-ending-variation", false, helpMsgDisableEndingVariation)
+	flag.StringVar(&opts.DisableRules, "disable-rules", "", helpMsgDisableRules)
+	flag.StringVar(&opts.DisableCategories, "disable-categories", "", helpMsgDisableCategories)
 	flag.Parse()
 	opts.Args = flag.Args()
 
+	// -seed 0 も有効なシード値として扱うため、指定の有無は別で判定する
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			opts.UseSeed = true
+		}
+	})
+
 	if err := opts.Validate(); err != nil {
 		return nil, err
 	}
@@ -50,9 +136,13 @@ func flagHelpMessage() {
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "Usage:")
 	fmt.Fprintln(os.Stderr, fmt.Sprintf("  %s [OPTIONS] [files...]", cmd))
+	fmt.Fprintln(os.Stderr, fmt.Sprintf("  %s rules export [-format yaml|json] [-o file]", cmd))
+	fmt.Fprintln(os.Stderr, fmt.Sprintf("  %s rules list", cmd))
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "Examples:")
 	fmt.Fprintln(os.Stderr, fmt.Sprintf("  %s sample.txt", cmd))
+	fmt.Fprintln(os.Stderr, fmt.Sprintf("  %s rules export -format json", cmd))
+	fmt.Fprintln(os.Stderr, fmt.Sprintf("  %s -disable-rules ending.か -disable-categories vulgar sample.txt", cmd))
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "Options:")
 
@@ -72,5 +162,148 @@ func (c *CmdArgs) Validate() error {
 		return fmt.Errorf("illegal completions. completions = %s", c.Completions)
 	}
 
+	if _, err := ojosama.ParseLevel(c.Level); err != nil {
+		return fmt.Errorf("level must be 'subtle', 'standard' or 'extreme'. level = %s", c.Level)
+	}
+
+	if _, err := ojosama.ParseMarkStyle(c.MarkStyle); err != nil {
+		return fmt.Errorf("mark-style must be 'random', 'fullwidth', 'halfwidth', 'emoji', 'double_emoji' or 'input'. mark-style = %s", c.MarkStyle)
+	}
+
+	if _, err := parseMarkWeights(c.MarkWeights); err != nil {
+		return err
+	}
+
+	if _, err := parseKutenWeights(c.KutenWeights); err != nil {
+		return err
+	}
+
+	if c.EndingMemory < 0 {
+		return fmt.Errorf("ending-variety must not be negative. ending-variety = %d", c.EndingMemory)
+	}
+
+	if c.MaxInputSize < 0 {
+		return fmt.Errorf("max-input-size must not be negative. max-input-size = %d", c.MaxInputSize)
+	}
+
+	if _, err := c.longNoteConfig(ojosama.LevelStandard); err != nil {
+		return err
+	}
+
+	if c.ReplaceRules && c.Rules == "" {
+		return errors.New("-replace-rules requires -rules.")
+	}
+
 	return nil
 }
+
+// longNoteConfig は波線や感嘆符の付け方の設定を返す。
+//
+// 関連するオプションが1つも指定されていない場合は、変換の強さの設定を使うため nil を返す。
+// 指定されたオプションだけ変換の強さ level の設定を上書きする。
+func (c *CmdArgs) longNoteConfig(level ojosama.Level) (*ojosama.LongNoteConfig, error) {
+	if c.WavyLine == "" && c.WavyCount == "" && c.MarkCount == "" && !c.ScaleNote {
+		return nil, nil
+	}
+
+	cfg := ojosama.DefaultLongNoteConfig(level)
+	if c.WavyLine != "" {
+		cfg.WavyLine = c.WavyLine
+	}
+	if c.WavyCount != "" {
+		r, err := parseCountRange(c.WavyCount)
+		if err != nil {
+			return nil, fmt.Errorf("wavy-count must be 'N' or 'MIN-MAX'. wavy-count = %s", c.WavyCount)
+		}
+		cfg.WavyLineCount = r
+	}
+	if c.MarkCount != "" {
+		r, err := parseCountRange(c.MarkCount)
+		if err != nil {
+			return nil, fmt.Errorf("mark-count must be 'N' or 'MIN-MAX'. mark-count = %s", c.MarkCount)
+		}
+		cfg.MarkCount = r
+	}
+	cfg.ScaleWithInput = c.ScaleNote
+	return &cfg, nil
+}
+
+// parseCountRange は "N" か "MIN-MAX" 形式の数の範囲を解析する。
+func parseCountRange(s string) (ojosama.CountRange, error) {
+	minStr, maxStr, ok := strings.Cut(s, "-")
+	if !ok {
+		maxStr = minStr
+	}
+	min, err := strconv.Atoi(strings.TrimSpace(minStr))
+	if err != nil {
+		return ojosama.CountRange{}, err
+	}
+	max, err := strconv.Atoi(strings.TrimSpace(maxStr))
+	if err != nil {
+		return ojosama.CountRange{}, err
+	}
+	if min < 0 || max < min {
+		return ojosama.CountRange{}, fmt.Errorf("illegal range: %s", s)
+	}
+	return ojosama.CountRange{Min: min, Max: max}, nil
+}
+
+// parseMarkWeights は "fullwidth=3,emoji=1" 形式の感嘆符・疑問符のスタイルごとの重みを解析する。
+func parseMarkWeights(s string) (map[ojosama.MarkStyle]int, error) {
+	items := splitList(s)
+	if len(items) < 1 {
+		return nil, nil
+	}
+	weights := make(map[ojosama.MarkStyle]int, len(items))
+	for _, item := range items {
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("mark-weights must be 'style=weight'. mark-weights = %s", item)
+		}
+		style, err := ojosama.ParseMarkStyle(strings.TrimSpace(name))
+		if err != nil {
+			return nil, fmt.Errorf("illegal mark-weights style. style = %s", name)
+		}
+		w, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("illegal mark-weights weight. weight = %s", value)
+		}
+		weights[style] = w
+	}
+	return weights, nil
+}
+
+// parseKutenWeights は "。=2,！=1,❗=1" 形式の句点を変換する時の候補と重みを解析する。
+//
+// 候補の並び順は引数の順序を維持する。
+func parseKutenWeights(s string) ([]ojosama.KutenWeight, error) {
+	items := splitList(s)
+	if len(items) < 1 {
+		return nil, nil
+	}
+	weights := make([]ojosama.KutenWeight, 0, len(items))
+	for _, item := range items {
+		value, weight, ok := strings.Cut(item, "=")
+		if !ok || strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("kuten-weights must be 'string=weight'. kuten-weights = %s", item)
+		}
+		w, err := strconv.Atoi(strings.TrimSpace(weight))
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("illegal kuten-weights weight. weight = %s", weight)
+		}
+		weights = append(weights, ojosama.KutenWeight{Value: strings.TrimSpace(value), Weight: w})
+	}
+	return weights, nil
+}
+
+// splitList はカンマ区切りの文字列を分割する。空の要素は無視する。
+func splitList(s string) []string {
+	var result []string
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -8,6 +8,9 @@ import (
 var (
 	paramCharCodes   = "utf8 sjis"
 	paramCompletions = "bash zsh fish"
+	paramLevels      = "subtle standard extreme"
+	paramCategories  = "other pronoun demonstrative ending adjective interjection vulgar name exclusion"
+	paramMarkStyles  = "random fullwidth halfwidth emoji double_emoji input"
 
 	completionsBash = strings.ReplaceAll(`# {{APPNAME}}(1) completion                                       -*- shell-script -*-
 
@@ -17,12 +20,15 @@ _{{APPNAME}}_module() {
 
   case "${cword}" in
     1)
-      local opts="-h -help -t -o -charcode -v -completions"
+      local opts="-h -help -t -o -charcode -v -completions -seed -rules -replace-rules -userdict -level -mark-style -mark-weights -kuten-weights -ending-variety -max-input-size -wavy-line -wavy-count -mark-count -scale-long-note -disable-kuten-to-exclamation -disable-prefix -disable-long-note -disable-pronoun -disable-demonstrative -disable-ending -disable-vulgar -disable-ending-variation -disable-rules -disable-categories `+cmdRules+`"
       COMPREPLY=($(compgen -W "${opts}" -- "${cur}"))
       ;;
     2)
       case "${prev}" in
-        -o)
+        `+cmdRules+`)
+          COMPREPLY=($(compgen -W "`+cmdRulesExport+` `+cmdRulesList+`" -- "${cur}"))
+          ;;
+        -o|-rules|-userdict)
           COMPREPLY=($(compgen -f -- "${cur}"))
           ;;
         -charcode)
@@ -33,6 +39,18 @@ _{{APPNAME}}_module() {
           local opts="`+paramCompletions+`"
           COMPREPLY=($(compgen -W "${opts}" -- "${cur}"))
           ;;
+        -level)
+          local opts="`+paramLevels+`"
+          COMPREPLY=($(compgen -W "${opts}" -- "${cur}"))
+          ;;
+        -mark-style)
+          local opts="`+paramMarkStyles+`"
+          COMPREPLY=($(compgen -W "${opts}" -- "${cur}"))
+          ;;
+        -disable-categories)
+          local opts="`+paramCategories+`"
+          COMPREPLY=($(compgen -W "${opts}" -- "${cur}"))
+          ;;
       esac
       ;;
   esac
@@ -49,7 +67,31 @@ _{{APPNAME}}() {
     -o'[`+helpMsgOutFile+`]:file:_files' \
     -charcode'[`+helpMsgCharCode+`]: :->charcode' \
     -v'[`+helpMsgVersion+`]: :->etc' \
-    -completions'[`+helpMsgCompletions+`]: :->completions'
+    -completions'[`+helpMsgCompletions+`]: :->completions' \
+    -seed'[`+helpMsgSeed+`]: :->etc' \
+    -rules'[`+helpMsgRules+`]:file:_files' \
+    -replace-rules'[`+helpMsgReplaceRules+`]: :->etc' \
+    -userdict'[`+helpMsgUserDict+`]:file:_files' \
+    -level'[`+helpMsgLevel+`]: :->level' \
+    -mark-style'[`+helpMsgMarkStyle+`]: :->markstyle' \
+    -mark-weights'[`+helpMsgMarkWeights+`]: :->etc' \
+    -kuten-weights'[`+helpMsgKutenWeights+`]: :->etc' \
+    -ending-variety'[`+helpMsgEndingMemory+`]: :->etc' \
+    -max-input-size'[`+helpMsgMaxInputSize+`]: :->etc' \
+    -wavy-line'[`+helpMsgWavyLine+`]: :->etc' \
+    -wavy-count'[`+helpMsgWavyCount+`]: :->etc' \
+    -mark-count'[`+helpMsgMarkCount+`]: :->etc' \
+    -scale-long-note'[`+helpMsgScaleNote+`]: :->etc' \
+    -disable-kuten-to-exclamation'[`+helpMsgDisableKutenToExclamation+`]: :->etc' \
+    -disable-prefix'[`+helpMsgDisableHonorificPrefix+`]: :->etc' \
+    -disable-long-note'[`+helpMsgDisableLongNote+`]: :->etc' \
+    -disable-pronoun'[`+helpMsgDisablePronoun+`]: :->etc' \
+    -disable-demonstrative'[`+helpMsgDisableDemonstrative+`]: :->etc' \
+    -disable-ending'[`+helpMsgDisableSentenceEnding+`]: :->etc' \
+    -disable-vulgar'[`+helpMsgDisableVulgar+`]: :->etc' \
+    -disable-ending-variation'[`+helpMsgDisableEndingVariation+`]: :->etc' \
+    -disable-rules'[`+helpMsgDisableRules+`]: :->etc' \
+    -disable-categories'[`+helpMsgDisableCategories+`]: :->categories'
 
   case "$state" in
     charcode)
@@ -58,6 +100,15 @@ _{{APPNAME}}() {
     completions)
       _values 'completions' `+paramCompletions+`
       ;;
+    level)
+      _values 'level' `+paramLevels+`
+      ;;
+    markstyle)
+      _values 'mark-style' `+paramMarkStyles+`
+      ;;
+    categories)
+      _values -s , 'categories' `+paramCategories+`
+      ;;
     etc)
       # nothing to do
       ;;
@@ -80,7 +131,35 @@ complete -c {{APPNAME}} -o t -x -d '`+helpMsgText+`'
 complete -c {{APPNAME}} -o o -r -d '`+helpMsgOutFile+`'
 complete -c {{APPNAME}} -o charcode -x -a '`+paramCharCodes+`' -d '`+helpMsgCharCode+`'
 complete -c {{APPNAME}} -o v -d '`+helpMsgVersion+`'
-complete -c {{APPNAME}} -o completions -x -a '`+paramCompletions+`' -d '`+helpMsgCompletions+`'`,
+complete -c {{APPNAME}} -o completions -x -a '`+paramCompletions+`' -d '`+helpMsgCompletions+`'
+complete -c {{APPNAME}} -o seed -x -d '`+helpMsgSeed+`'
+complete -c {{APPNAME}} -o rules -r -d '`+helpMsgRules+`'
+complete -c {{APPNAME}} -o replace-rules -d '`+helpMsgReplaceRules+`'
+complete -c {{APPNAME}} -o userdict -r -d '`+helpMsgUserDict+`'
+complete -c {{APPNAME}} -o level -x -a '`+paramLevels+`' -d '`+helpMsgLevel+`'
+complete -c {{APPNAME}} -o mark-style -x -a '`+paramMarkStyles+`' -d '`+helpMsgMarkStyle+`'
+complete -c {{APPNAME}} -o mark-weights -x -d '`+helpMsgMarkWeights+`'
+complete -c {{APPNAME}} -o kuten-weights -x -d '`+helpMsgKutenWeights+`'
+complete -c {{APPNAME}} -o ending-variety -x -d '`+helpMsgEndingMemory+`'
+complete -c {{APPNAME}} -o max-input-size -x -d '`+helpMsgMaxInputSize+`'
+complete -c {{APPNAME}} -o wavy-line -x -a '～ 〜 ー' -d '`+helpMsgWavyLine+`'
+complete -c {{APPNAME}} -o wavy-count -x -d '`+helpMsgWavyCount+`'
+complete -c {{APPNAME}} -o mark-count -x -d '`+helpMsgMarkCount+`'
+complete -c {{APPNAME}} -o scale-long-note -d '`+helpMsgScaleNote+`'
+complete -c {{APPNAME}} -o disable-kuten-to-exclamation -d '`+helpMsgDisableKutenToExclamation+`'
+complete -c {{APPNAME}} -o disable-prefix -d '`+helpMsgDisableHonorificPrefix+`'
+complete -c {{APPNAME}} -o disable-long-note -d '`+helpMsgDisableLongNote+`'
+complete -c {{APPNAME}} -o disable-pronoun -d '`+helpMsgDisablePronoun+`'
+complete -c {{APPNAME}} -o disable-demonstrative -d '`+helpMsgDisableDemonstrative+`'
+complete -c {{APPNAME}} -o disable-ending -d '`+helpMsgDisableSentenceEnding+`'
+complete -c {{APPNAME}} -o disable-vulgar -d '`+helpMsgDisableVulgar+`'
+complete -c {{APPNAME}} -o disable-ending-variation -d '`+helpMsgDisableEndingVariation+`'
+complete -c {{APPNAME}} -o disable-rules -x -d '`+helpMsgDisableRules+`'
+complete -c {{APPNAME}} -o disable-categories -x -a '`+paramCategories+`' -d '`+helpMsgDisableCategories+`'
+complete -c {{APPNAME}} -n '__fish_use_subcommand' -a `+cmdRules+` -d 'manage conversion rules'
+complete -c {{APPNAME}} -n '__fish_seen_subcommand_from `+cmdRules+`' -a `+cmdRulesExport+` -d 'export built-in rules'
+complete -c {{APPNAME}} -n '__fish_seen_subcommand_from `+cmdRules+`' -a `+cmdRulesList+` -d 'list built-in rule IDs'
+complete -c {{APPNAME}} -n '__fish_seen_subcommand_from `+cmdRulesExport+`' -o format -x -a '`+paramRulesFormats+`' -d '`+helpMsgRulesFormat+`'`,
 		"{{APPNAME}}", appName)
 
 	completionsMap = map[string]string{
@@ -1,12 +1,12 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
-	"math/rand"
 	"os"
-	"time"
 
+	"github.com/ikawaha/kagome-dict/dict"
 	"github.com/jiro4989/ojosama"
 	"golang.org/x/text/encoding/japanese"
 	"golang.org/x/text/transform"
@@ -29,9 +29,23 @@ const (
 	exitStatusConvertError
 	exitStatusInputFileError
 	exitStatusOutputError
+	exitStatusRuleFileError
+	exitStatusUserDictError
+	exitStatusInvalidInputError
+	exitStatusInputTooLargeError
+	exitStatusTokenizerError
 )
 
 func main() {
+	// サブコマンドはフラグの解析より先に判定する
+	if 1 < len(os.Args) && os.Args[1] == cmdRules {
+		exitStatus, err := runRules(os.Args[2:])
+		if err != nil {
+			Err(err)
+		}
+		os.Exit(exitStatus)
+	}
+
 	args, err := ParseArgs()
 	if err != nil {
 		Err(err)
@@ -52,8 +66,14 @@ func main() {
 		return
 	}
 
+	c, exitStatus, err := newConverter(args)
+	if err != nil {
+		Err(err)
+		os.Exit(exitStatus)
+	}
+
 	if args.Text != "" {
-		exitStatus, err := run(args.Text, args)
+		exitStatus, err := run(c, args.Text, args)
 		if err != nil {
 			Err(err)
 			os.Exit(exitStatus)
@@ -62,20 +82,7 @@ func main() {
 	}
 
 	if len(args.Args) < 1 {
-		// SJIS指定の時だけSJISとして読み込む
-		var r io.Reader = os.Stdin
-		if args.CharCode == "sjis" {
-			r = transform.NewReader(os.Stdin, japanese.ShiftJIS.NewDecoder())
-		}
-
-		b, err := io.ReadAll(r)
-		if err != nil {
-			Err(err)
-			os.Exit(exitStatusInputFileError)
-		}
-
-		s := string(b)
-		exitStatus, err := run(s, args)
+		exitStatus, err := runStream(c, os.Stdin, args)
 		if err != nil {
 			Err(err)
 			os.Exit(exitStatus)
@@ -91,35 +98,75 @@ func main() {
 		}
 		defer f.Close()
 
-		// SJIS指定の時だけSJISとして読み込む
-		var r io.Reader = f
-		if args.CharCode == "sjis" {
-			r = transform.NewReader(f, japanese.ShiftJIS.NewDecoder())
+		exitStatus, err := runStream(c, f, args)
+		if err != nil {
+			Err(err)
+			os.Exit(exitStatus)
 		}
+	}
+
+	os.Exit(exitStatusOK)
+}
+
+// newConverter はコマンドライン引数の設定で Converter を生成する。
+func newConverter(args *CmdArgs) (*ojosama.Converter, int, error) {
+	opt := ojosama.ConvertOption{
+		DisableKutenToExclamation: args.DisableKutenToExclamation,
+		DisableHonorificPrefix:    args.DisableHonorificPrefix,
+		DisableLongNote:           args.DisableLongNote,
+		DisablePronoun:            args.DisablePronoun,
+		DisableDemonstrative:      args.DisableDemonstrative,
+		DisableSentenceEnding:     args.DisableSentenceEnding,
+		DisableVulgar:             args.DisableVulgar,
+		DisableEndingVariation:    args.DisableEndingVariation,
+		DisableRules:              splitList(args.DisableRules),
+	}
+	for _, cat := range splitList(args.DisableCategories) {
+		opt.DisableCategories = append(opt.DisableCategories, ojosama.Category(cat))
+	}
+	// レベルやスタイル、波線の設定は引数の検証時に確認済み
+	opt.Level, _ = ojosama.ParseLevel(args.Level)
+	opt.MarkStyle, _ = ojosama.ParseMarkStyle(args.MarkStyle)
+	opt.MarkStyleWeights, _ = parseMarkWeights(args.MarkWeights)
+	opt.KutenWeights, _ = parseKutenWeights(args.KutenWeights)
+	if 0 < args.EndingMemory {
+		opt.EndingVariety = &ojosama.EndingVarietyConfig{Memory: args.EndingMemory}
+	}
+	opt.LongNote, _ = args.longNoteConfig(opt.Level)
+	if args.UseSeed {
+		opt.Seed = &args.Seed
+	}
+	opt.MaxInputSize = args.MaxInputSize
 
-		b, err := io.ReadAll(r)
+	if args.Rules != "" {
+		rs, err := ojosama.LoadRuleFile(args.Rules)
 		if err != nil {
-			Err(err)
-			os.Exit(exitStatusInputFileError)
+			return nil, exitStatusRuleFileError, err
 		}
+		// ルールファイルのルールは組み込みのルールより優先する
+		opt.PrependRules = rs
+		opt.DisableBuiltinRules = args.ReplaceRules
+	}
 
-		s := string(b)
-		exitStatus, err := run(s, args)
+	if args.UserDict != "" {
+		d, err := dict.NewUserDict(args.UserDict)
 		if err != nil {
-			Err(err)
-			os.Exit(exitStatus)
+			return nil, exitStatusUserDictError, err
 		}
+		opt.UserDict = d
 	}
 
-	os.Exit(exitStatusOK)
+	c, err := ojosama.NewConverter(&opt)
+	if err != nil {
+		return nil, convertErrorExitStatus(err, exitStatusConvertError), err
+	}
+	return c, exitStatusOK, nil
 }
 
-func run(s string, args *CmdArgs) (int, error) {
-	rand.Seed(time.Now().UnixNano())
-
-	text, err := ojosama.Convert(s, nil)
+func run(c *ojosama.Converter, s string, args *CmdArgs) (int, error) {
+	text, err := c.Convert(s)
 	if err != nil {
-		return exitStatusConvertError, err
+		return convertErrorExitStatus(err, exitStatusConvertError), err
 	}
 
 	out := os.Stdout
@@ -133,3 +180,57 @@ func run(s string, args *CmdArgs) (int, error) {
 	out.WriteString(text)
 	return exitStatusOK, nil
 }
+
+// runStream は r から読み込んだテキストを逐次変換して出力する。
+//
+// 入力全体をメモリに載せないように、文の区切りごとに変換して書き込む。
+func runStream(c *ojosama.Converter, r io.Reader, args *CmdArgs) (int, error) {
+	// SJIS指定の時だけSJISとして読み込む
+	t := c.NewTransformer()
+	if args.CharCode == "sjis" {
+		t = transform.Chain(japanese.ShiftJIS.NewDecoder(), t)
+	}
+
+	out := os.Stdout
+	if args.OutFile != "" {
+		var err error
+		out, err = os.Create(args.OutFile)
+		if err != nil {
+			return exitStatusOutputError, err
+		}
+		defer out.Close()
+	}
+
+	// 読み込みのエラーと書き込みのエラーで終了ステータスを分けるため io.Copy は使わない
+	tr := transform.NewReader(r, t)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := tr.Read(buf)
+		if 0 < n {
+			if _, err := out.Write(buf[:n]); err != nil {
+				return exitStatusOutputError, err
+			}
+		}
+		if err == io.EOF {
+			return exitStatusOK, nil
+		}
+		if err != nil {
+			return convertErrorExitStatus(err, exitStatusInputFileError), err
+		}
+	}
+}
+
+// convertErrorExitStatus は変換のエラーに対応する終了ステータスを返す。
+//
+// 変換のエラーでない場合は defaultStatus を返す。
+func convertErrorExitStatus(err error, defaultStatus int) int {
+	switch {
+	case errors.Is(err, ojosama.ErrInvalidUTF8), errors.Is(err, ojosama.ErrNULByte):
+		return exitStatusInvalidInputError
+	case errors.Is(err, ojosama.ErrInputTooLarge):
+		return exitStatusInputTooLargeError
+	case errors.Is(err, ojosama.ErrTokenizerInit):
+		return exitStatusTokenizerError
+	}
+	return defaultStatus
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jiro4989/ojosama"
+)
+
+const (
+	cmdRules       = "rules"
+	cmdRulesExport = "export"
+	cmdRulesList   = "list"
+
+	helpMsgRulesFormat  = "output format. (yaml, json)"
+	helpMsgRulesOutFile = "output file"
+)
+
+var paramRulesFormats = "yaml json"
+
+// runRules は変換ルールを操作するサブコマンドを実行する。
+//
+// args はサブコマンド名より後ろの引数。
+func runRules(args []string) (int, error) {
+	if 0 < len(args) {
+		switch args[0] {
+		case cmdRulesExport:
+			return runRulesExport(args[1:])
+		case cmdRulesList:
+			return runRulesList()
+		}
+	}
+	return exitStatusCLIError, fmt.Errorf("usage: %s %s %s [-format yaml|json] [-o file] | %s %s %s", appName, cmdRules, cmdRulesExport, appName, cmdRules, cmdRulesList)
+}
+
+// runRulesList は組み込みの変換ルールのID、分類、説明を1行ずつ出力する。
+//
+// -disable-rules や -disable-categories に指定する値を調べる時に使う。
+func runRulesList() (int, error) {
+	rs := ojosama.BuiltinRules()
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCATEGORY\tDESCRIPTION")
+	for _, r := range rs.SentenceEndingRules {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.ID, r.Category, r.Description)
+	}
+	for _, r := range rs.ContinuousRules {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.ID, r.Category, r.Description)
+	}
+	for _, r := range rs.ExcludeRules {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.ID, ojosama.CategoryExclusion, r.Description)
+	}
+	for _, r := range rs.ConvertRules {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.ID, r.Category, r.Description)
+	}
+	if err := w.Flush(); err != nil {
+		return exitStatusOutputError, err
+	}
+	return exitStatusOK, nil
+}
+
+// runRulesExport は組み込みの変換ルールを変換ルールファイルの形式で出力する。
+func runRulesExport(args []string) (int, error) {
+	fs := flag.NewFlagSet(fmt.Sprintf("%s %s %s", appName, cmdRules, cmdRulesExport), flag.ContinueOnError)
+	format := fs.String("format", "yaml", helpMsgRulesFormat)
+	outFile := fs.String("o", "", helpMsgRulesOutFile)
+	if err := fs.Parse(args); err != nil {
+		return exitStatusCLIError, err
+	}
+
+	var f ojosama.RuleFileFormat
+	switch *format {
+	case "yaml":
+		f = ojosama.RuleFileFormatYAML
+	case "json":
+		f = ojosama.RuleFileFormatJSON
+	default:
+		return exitStatusCLIError, errors.New("format must be 'yaml' or 'json'.")
+	}
+
+	b, err := ojosama.MarshalRuleFile(ojosama.BuiltinRules(), f)
+	if err != nil {
+		return exitStatusConvertError, err
+	}
+
+	out := os.Stdout
+	if *outFile != "" {
+		out, err = os.Create(*outFile)
+		if err != nil {
+			return exitStatusOutputError, err
+		}
+		defer out.Close()
+	}
+	if _, err := out.Write(b); err != nil {
+		return exitStatusOutputError, err
+	}
+	return exitStatusOK, nil
+}
@@ -0,0 +1,54 @@
+package ojosama
+
+import (
+	"context"
+)
+
+// cancelCheckInterval は変換の途中でキャンセルされたかを確認する単語の間隔。
+//
+// 単語ごとに確認するとコストがかかるため、ある程度まとめて確認する。
+const cancelCheckInterval = 256
+
+// ConvertContext は Convert と同じ変換を行う。
+//
+// 変換の途中で ctx がキャンセルされるか期限を過ぎた場合は、変換を中断して ctx のエラーを返す。
+// キャンセルは単語をいくつか変換するごとに確認するため、すぐには中断しないことがある。
+// また、形態素解析の途中ではキャンセルを確認しないため、
+// 大きなテキストを受け付ける場合は opt の MaxInputSize で大きさを制限すること。
+func ConvertContext(ctx context.Context, src string, opt *ConvertOption) (string, error) {
+	c, err := converterFor(opt)
+	if err != nil {
+		return "", err
+	}
+	if err := c.validateDisableRules(opt); err != nil {
+		return "", err
+	}
+	return c.convert(ctx, src, opt)
+}
+
+// ConvertContext は Convert と同じ変換を行う。
+//
+// 変換の途中で ctx がキャンセルされるか期限を過ぎた場合は、変換を中断して ctx のエラーを返す。
+func (c *Converter) ConvertContext(ctx context.Context, src string) (string, error) {
+	return c.convert(ctx, src, c.opt)
+}
+
+// cancelChecker は変換の途中で一定の間隔ごとにキャンセルされたかを確認する。
+type cancelChecker struct {
+	ctx context.Context
+	n   int
+}
+
+func newCancelChecker(ctx context.Context) *cancelChecker {
+	return &cancelChecker{ctx: ctx}
+}
+
+// check は cancelCheckInterval 回呼ばれるごとに ctx のエラーを返す。
+func (c *cancelChecker) check() error {
+	c.n++
+	if c.n < cancelCheckInterval {
+		return nil
+	}
+	c.n = 0
+	return c.ctx.Err()
+}
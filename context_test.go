@@ -0,0 +1,157 @@
+package ojosama
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countdownContext は Err が一定回数呼ばれた後にキャンセルされたことにする context 。
+type countdownContext struct {
+	context.Context
+	remain int
+	calls  int
+}
+
+func (c *countdownContext) Err() error {
+	c.calls++
+	if c.remain < c.calls {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestConvertContext(t *testing.T) {
+	src := "ハーブです！これはハーブです。"
+	seed := int64(1)
+	opt := &ConvertOption{Seed: &seed}
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		desc    string
+		ctx     context.Context
+		wantErr error
+	}{
+		{
+			desc:    "正常系: キャンセルされなければ Convert と同じ結果になりますわ",
+			ctx:     context.Background(),
+			wantErr: nil,
+		},
+		{
+			desc:    "異常系: キャンセルされた場合はエラーですわ",
+			ctx:     canceled,
+			wantErr: context.Canceled,
+		},
+		{
+			desc:    "異常系: 期限を過ぎた場合はエラーですわ",
+			ctx:     expired,
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+
+	want, err := Convert(src, opt)
+	assert.NoError(t, err)
+	c, err := NewConverter(opt)
+	assert.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := ConvertContext(tt.ctx, src, opt)
+			assert.ErrorIs(err, tt.wantErr)
+			got2, err2 := c.ConvertContext(tt.ctx, src)
+			assert.ErrorIs(err2, tt.wantErr)
+			if tt.wantErr != nil {
+				assert.Empty(got)
+				assert.Empty(got2)
+				return
+			}
+			assert.Equal(want, got)
+			assert.Equal(want, got2)
+		})
+	}
+}
+
+func TestConvertContextCancelDuringConversion(t *testing.T) {
+	assert := assert.New(t)
+
+	// 変換を始めた後でキャンセルされた場合も途中で中断いたしますわ
+	ctx := &countdownContext{Context: context.Background(), remain: 3}
+	src := strings.Repeat("ハーブです。", cancelCheckInterval*2)
+	got, err := ConvertContext(ctx, src, nil)
+	assert.ErrorIs(err, context.Canceled)
+	assert.Empty(got)
+	assert.Less(3, ctx.calls)
+}
+
+func TestConvertMaxInputSize(t *testing.T) {
+	opt := &ConvertOption{
+		DisableKutenToExclamation: true,
+		MaxInputSize:              len("ハーブです。"),
+	}
+	c, err := NewConverter(opt)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		desc    string
+		src     string
+		wantErr bool
+	}{
+		{
+			desc:    "正常系: 最大の大きさちょうどの場合は変換いたしますわ",
+			src:     "ハーブです。",
+			wantErr: false,
+		},
+		{
+			desc:    "異常系: 最大の大きさを超える場合はエラーですわ",
+			src:     "ハーブです。ハーブです。",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// どの変換の方法でも同じ判定になりますわ
+			_, err1 := Convert(tt.src, opt)
+			_, err2 := c.Convert(tt.src)
+			_, err3 := ConvertContext(context.Background(), tt.src, opt)
+			_, err4 := ConvertSegments(tt.src, opt)
+			_, _, err5 := ConvertWithTrace(tt.src, opt)
+			_, err6 := io.ReadAll(c.NewReader(strings.NewReader(tt.src)))
+
+			for _, err := range []error{err1, err2, err3, err4, err5, err6} {
+				if !tt.wantErr {
+					assert.NoError(err)
+					continue
+				}
+				var e *InputTooLargeError
+				if assert.True(errors.As(err, &e)) {
+					assert.Equal(len(tt.src), e.Size)
+					assert.Equal(opt.MaxInputSize, e.Max)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertInvalidMaxInputSize(t *testing.T) {
+	assert := assert.New(t)
+
+	opt := &ConvertOption{MaxInputSize: -1}
+	_, err := Convert("ハーブです", opt)
+	assert.ErrorIs(err, errInvalidMaxInputSize)
+	_, err = NewConverter(opt)
+	assert.ErrorIs(err, errInvalidMaxInputSize)
+}
@@ -0,0 +1,310 @@
+package ojosama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/ikawaha/kagome-dict/dict"
+	"github.com/ikawaha/kagome-dict/ipa"
+	"github.com/ikawaha/kagome/v2/tokenizer"
+	"github.com/jiro4989/ojosama/internal/converter"
+)
+
+// Converter はお嬢様変換を行う変換器。
+//
+// 形態素解析器の初期化は辞書の読み込みを伴うため、
+// 毎回生成するとそれなりのコストがかかる。
+// Converter は形態素解析器と変換ルールを保持し、
+// 一度生成したら何度でも使い回せる。
+//
+// Converter は複数のゴルーチンから同時に使用しても安全。
+type Converter struct {
+	tokenizer *tokenizer.Tokenizer
+	opt       *ConvertOption
+
+	ojosamaStyleRules                  []converter.ConvertConditions
+	sentenceEndingParticleConvertRules []converter.SentenceEndingParticleConvertRule
+	continuousConditionsConvertRules   []converter.ContinuousConditionsConvertRule
+	excludeRules                       []converter.ConvertRule
+	convertRules                       []converter.ConvertRule
+
+	// 変換ルールを Token から引くための索引
+	continuousRuleIndex *converter.RuleIndex
+	excludeRuleIndex    *converter.RuleIndex
+	convertRuleIndex    *converter.RuleIndex
+
+	ruleIDs map[string]struct{} // すべての変換ルールのID
+
+	forceLinearRuleMatch bool // 単体テスト用のパラメータ。索引を使わずにすべての変換ルールを順に判定する
+}
+
+var (
+	defaultConverter     *Converter
+	defaultConverterErr  error
+	defaultConverterOnce sync.Once
+
+	// ユーザー辞書を使わない Converter で共有する形態素解析器
+	sharedTokenizer     *tokenizer.Tokenizer
+	sharedTokenizerErr  error
+	sharedTokenizerOnce sync.Once
+)
+
+// NewConverter は Converter を生成する。
+//
+// opt は挙動を微調整するためのオプショナルなパラメータ。
+// 不要であれば nil を渡せば良い。
+func NewConverter(opt *ConvertOption) (*Converter, error) {
+	if err := validateOption(opt); err != nil {
+		return nil, err
+	}
+
+	var userDict *dict.UserDict
+	var prepend, append_ *RuleSet
+	disableBuiltin := false
+	if opt != nil {
+		userDict = opt.UserDict
+		prepend = opt.PrependRules
+		append_ = opt.AppendRules
+		disableBuiltin = opt.DisableBuiltinRules
+	}
+
+	t, err := getTokenizer(userDict)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := prepend.Validate(); err != nil {
+		return nil, err
+	}
+	if err := append_.Validate(); err != nil {
+		return nil, err
+	}
+
+	builtinContinuous := converter.ContinuousConditionsConvertRules
+	builtinExclude := converter.ExcludeRules
+	builtinConvert := converter.ConvertRules
+	builtinSentenceEnding := converter.SentenceEndingParticleConvertRules
+	if disableBuiltin {
+		builtinSentenceEnding = nil
+		builtinContinuous = nil
+		builtinExclude = nil
+		builtinConvert = nil
+	}
+
+	// 呼び出し側で opt を書き換えられても影響を受けないようにコピーする
+	o := opt.clone()
+	c := &Converter{
+		tokenizer:                          t,
+		opt:                                o,
+		ojosamaStyleRules:                  converter.OjosamaStyleRules,
+		sentenceEndingParticleConvertRules: concatRules(prepend.sentenceEndingRules(), builtinSentenceEnding, append_.sentenceEndingRules()),
+		continuousConditionsConvertRules:   concatRules(prepend.continuousRules(), builtinContinuous, append_.continuousRules()),
+		excludeRules:                       concatRules(prepend.excludeRules(), builtinExclude, append_.excludeRules()),
+		convertRules:                       concatRules(prepend.convertRules(), builtinConvert, append_.convertRules()),
+	}
+	c.continuousRuleIndex = converter.NewContinuousRuleIndex(c.continuousConditionsConvertRules)
+	c.excludeRuleIndex = converter.NewConvertRuleIndex(c.excludeRules)
+	c.convertRuleIndex = converter.NewConvertRuleIndex(c.convertRules)
+	c.ruleIDs = c.collectRuleIDs()
+	if err := c.validateDisableRules(o); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// getTokenizer は userDict を使う形態素解析器を返す。
+//
+// 辞書の読み込みにはコストがかかるため、userDict が nil の場合は
+// パッケージ内で共有する形態素解析器を初回呼び出し時に1度だけ生成して使い回す。
+func getTokenizer(userDict *dict.UserDict) (*tokenizer.Tokenizer, error) {
+	if userDict == nil {
+		sharedTokenizerOnce.Do(func() {
+			sharedTokenizer, sharedTokenizerErr = newTokenizer(nil)
+		})
+		return sharedTokenizer, sharedTokenizerErr
+	}
+	return newTokenizer(userDict)
+}
+
+// newTokenizer は userDict を使う形態素解析器を生成する。userDict は nil でも良い。
+func newTokenizer(userDict *dict.UserDict) (*tokenizer.Tokenizer, error) {
+	opts := []tokenizer.Option{tokenizer.OmitBosEos()}
+	if userDict != nil {
+		opts = append(opts, tokenizer.UserDict(userDict))
+	}
+	t, err := tokenizer.New(ipa.Dict(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenizerInit, err)
+	}
+	return t, nil
+}
+
+// clone は opt の複製を返す。opt が nil の場合は nil を返す。
+//
+// ポインタ、スライス、マップの項目も複製し、呼び出し側と共有しないようにする。
+// 独自の変換ルールは Converter の生成時に変換エンジンの形式に複製するため、保持しない。
+// ユーザー辞書は生成後に書き換えられないため共有する。
+func (opt *ConvertOption) clone() *ConvertOption {
+	if opt == nil {
+		return nil
+	}
+	o := *opt
+	o.PrependRules = nil
+	o.AppendRules = nil
+	o.DisableRules = slices.Clone(opt.DisableRules)
+	o.DisableCategories = slices.Clone(opt.DisableCategories)
+	o.MarkStyleWeights = maps.Clone(opt.MarkStyleWeights)
+	o.KutenWeights = slices.Clone(opt.KutenWeights)
+	if opt.Seed != nil {
+		seed := *opt.Seed
+		o.Seed = &seed
+	}
+	if opt.LongNote != nil {
+		longNote := *opt.LongNote
+		o.LongNote = &longNote
+	}
+	if opt.EndingVariety != nil {
+		ev := *opt.EndingVariety
+		if ev.Alternatives != nil {
+			ev.Alternatives = make([][]string, 0, len(opt.EndingVariety.Alternatives))
+			for _, v := range opt.EndingVariety.Alternatives {
+				ev.Alternatives = append(ev.Alternatives, copyStrings(v))
+			}
+		}
+		o.EndingVariety = &ev
+	}
+	if opt.forceCharsTestMode != nil {
+		tm := *opt.forceCharsTestMode
+		o.forceCharsTestMode = &tm
+	}
+	return &o
+}
+
+// ruleCandidates は索引 x から data にマッチしうる変換ルールの位置を昇順に返す。
+//
+// n は変換ルールの数で、索引を使わない場合はすべての変換ルールを返す。
+func (c *Converter) ruleCandidates(x *converter.RuleIndex, n int, data tokenizer.TokenData) iter.Seq[int] {
+	if c.forceLinearRuleMatch {
+		return func(yield func(int) bool) {
+			for i := range n {
+				if !yield(i) {
+					return
+				}
+			}
+		}
+	}
+	return x.Candidates(data)
+}
+
+// collectRuleIDs は Converter が持つすべての変換ルールのIDを返す。
+func (c *Converter) collectRuleIDs() map[string]struct{} {
+	ids := make(map[string]struct{})
+	for _, r := range c.sentenceEndingParticleConvertRules {
+		ids[r.ID] = struct{}{}
+	}
+	for _, r := range c.continuousConditionsConvertRules {
+		ids[r.ID] = struct{}{}
+	}
+	for _, r := range c.excludeRules {
+		ids[r.ID] = struct{}{}
+	}
+	for _, r := range c.convertRules {
+		ids[r.ID] = struct{}{}
+	}
+	delete(ids, "")
+	return ids
+}
+
+// validateDisableRules は opt で無効にする変換ルールのIDが存在するかを検証する。
+//
+// 独自の変換ルールによって存在するIDが変わるため、Converter ごとに検証する。
+func (c *Converter) validateDisableRules(opt *ConvertOption) error {
+	if opt == nil {
+		return nil
+	}
+	for _, id := range opt.DisableRules {
+		if _, ok := c.ruleIDs[id]; !ok {
+			return fmt.Errorf("%w: %q", errUnknownRuleID, id)
+		}
+	}
+	return nil
+}
+
+// validateOption は opt の設定値が正しいかを検証する。
+func validateOption(opt *ConvertOption) error {
+	if opt == nil {
+		return nil
+	}
+	if err := opt.Level.validate(); err != nil {
+		return err
+	}
+	if err := opt.MarkStyle.validate(); err != nil {
+		return err
+	}
+	if err := validateMarkStyleWeights(opt.MarkStyleWeights); err != nil {
+		return err
+	}
+	if err := opt.LongNote.validate(); err != nil {
+		return err
+	}
+	if err := validateKutenWeights(opt.KutenWeights); err != nil {
+		return err
+	}
+	if err := opt.EndingVariety.validate(); err != nil {
+		return err
+	}
+	if opt.MaxInputSize < 0 {
+		return fmt.Errorf("%w: %d", errInvalidMaxInputSize, opt.MaxInputSize)
+	}
+	for _, cat := range opt.DisableCategories {
+		if cat == "" {
+			return fmt.Errorf("%w: %q", errUnknownCategory, cat)
+		}
+		if err := cat.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	errUnknownRuleID       = errors.New("unknown rule id")
+	errInvalidMaxInputSize = errors.New("max input size must not be negative")
+)
+
+// getDefaultConverter はパッケージ共有の Converter を返す。
+//
+// 共有の Converter は初回呼び出し時に1度だけ生成する。
+func getDefaultConverter() (*Converter, error) {
+	defaultConverterOnce.Do(func() {
+		defaultConverter, defaultConverterErr = NewConverter(nil)
+	})
+	return defaultConverter, defaultConverterErr
+}
+
+// converterFor は opt で変換する時に使う Converter を返す。
+//
+// opt が Converter の生成時にしか反映できない設定を持つ場合は、
+// 共有の Converter ではなく新しく Converter を生成する。
+// その場合もユーザー辞書を使わなければ形態素解析器は共有のものを使う。
+func converterFor(opt *ConvertOption) (*Converter, error) {
+	if err := validateOption(opt); err != nil {
+		return nil, err
+	}
+	if opt != nil && (opt.PrependRules != nil || opt.AppendRules != nil || opt.DisableBuiltinRules || opt.UserDict != nil) {
+		return NewConverter(opt)
+	}
+	return getDefaultConverter()
+}
+
+// Convert はテキストを壱百満天原サロメお嬢様風の口調に変換して返却する。
+//
+// 変換時のオプションには NewConverter に渡した opt を使用する。
+func (c *Converter) Convert(src string) (string, error) {
+	return c.convert(context.Background(), src, c.opt)
+}
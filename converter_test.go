@@ -0,0 +1,313 @@
+package ojosama
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConverterConvert(t *testing.T) {
+	tests := []struct {
+		desc    string
+		src     string
+		opt     *ConvertOption
+		want    string
+		wantErr bool
+	}{
+		{
+			desc: "正常系: Converterでも変換いたしますわ",
+			src:  "これはハーブです",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+			},
+			want:    "こちらはおハーブですわ",
+			wantErr: false,
+		},
+		{
+			desc:    "正常系: オプションはnilでも問題ありませんわ",
+			src:     "〇〇をプレイする",
+			opt:     nil,
+			want:    "〇〇をプレイいたしますわ",
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			c, err := NewConverter(tt.opt)
+			assert.NoError(err)
+
+			got, err := c.Convert(tt.src)
+			if tt.wantErr {
+				assert.Error(err)
+				assert.Empty(got)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tt.want, got)
+		})
+	}
+}
+
+func TestNewConverterCopiesOption(t *testing.T) {
+	assert := assert.New(t)
+
+	seed := int64(1)
+	prepend := &RuleSet{
+		ConvertRules: []Rule{
+			{
+				Conditions: []Condition{{Features: []string{"名詞", "一般"}, Surface: "ハーブ"}},
+				Value:      "ハーブティー",
+				ID:         "custom.ハーブ",
+			},
+		},
+	}
+	opt := &ConvertOption{
+		DisableKutenToExclamation: true,
+		Seed:                      &seed,
+		DisableRules:              []string{"ending.か"},
+		DisableCategories:         []Category{CategoryDemonstrative},
+		MarkStyleWeights:          map[MarkStyle]int{MarkStyleEmoji: 1},
+		KutenWeights:              []KutenWeight{{Value: "。", Weight: 1}},
+		LongNote:                  FixedLongNote(1, 1),
+		PrependRules:              prepend,
+	}
+	c, err := NewConverter(opt)
+	assert.NoError(err)
+
+	src := "これはハーブですか？ハーブです。"
+	want, err := c.Convert(src)
+	assert.NoError(err)
+
+	// 生成後にオプションを書き換えても影響を受けませんわ
+	opt.DisableKutenToExclamation = false
+	seed = 2
+	opt.DisableRules[0] = "ending.です"
+	opt.DisableCategories[0] = CategoryEnding
+	opt.MarkStyleWeights[MarkStyleEmoji] = 0
+	opt.MarkStyleWeights[MarkStyleFullWidth] = 1
+	opt.KutenWeights[0] = KutenWeight{Value: "❗", Weight: 1}
+	opt.LongNote.WavyLineCount = CountRange{Min: 3, Max: 3}
+	prepend.ConvertRules[0].Conditions[0].Features[0] = "動詞"
+	prepend.ConvertRules[0].Value = "ハーブ園"
+
+	assert.True(c.opt.DisableKutenToExclamation)
+	got, err := c.Convert(src)
+	assert.NoError(err)
+	assert.Equal(want, got)
+}
+
+func TestConverterForSharesTokenizer(t *testing.T) {
+	assert := assert.New(t)
+
+	def, err := getDefaultConverter()
+	assert.NoError(err)
+
+	// 独自の変換ルールを指定しても、ユーザー辞書が無ければ形態素解析器は共有いたしますわ
+	c, err := converterFor(&ConvertOption{DisableBuiltinRules: true})
+	assert.NoError(err)
+	assert.NotSame(def, c)
+	assert.Same(def.tokenizer, c.tokenizer)
+}
+
+// TestConverterConvertConcurrently は複数のゴルーチンから同時に変換しても
+// 共有の状態を書き換えないことを確認する。
+//
+// go test -race で実行することでデータ競合を検出する。
+func TestConverterConvertConcurrently(t *testing.T) {
+	assert := assert.New(t)
+
+	b, err := os.ReadFile("testdata/sample1.txt")
+	assert.NoError(err)
+	src := string(b)
+
+	seed := int64(1)
+	c, err := NewConverter(&ConvertOption{Seed: &seed})
+	assert.NoError(err)
+
+	want, err := c.Convert(src)
+	assert.NoError(err)
+
+	const n = 16
+	var wg sync.WaitGroup
+	results := make([]string, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// シード値を指定していない変換も同時に走らせる
+			if _, err := Convert(src, nil); err != nil {
+				errs[i] = err
+				return
+			}
+			results[i], errs[i] = c.Convert(src)
+		}(i)
+	}
+	wg.Wait()
+
+	// 同じシード値であればどのゴルーチンでも同じ結果になりますわ
+	for i := 0; i < n; i++ {
+		assert.NoError(errs[i])
+		assert.Equal(want, results[i])
+	}
+}
+
+func TestConverterRuleIndexEquivalence(t *testing.T) {
+	// 組み込みの変換ルールの変換例と testdata の文章を対象にする
+	var corpus []string
+	rs := BuiltinRules()
+	for _, r := range rs.SentenceEndingRules {
+		for _, e := range r.Examples {
+			corpus = append(corpus, e.Input)
+		}
+	}
+	for _, r := range rs.ContinuousRules {
+		for _, e := range r.Examples {
+			corpus = append(corpus, e.Input)
+		}
+	}
+	for _, r := range rs.ExcludeRules {
+		for _, e := range r.Examples {
+			corpus = append(corpus, e.Input)
+		}
+	}
+	for _, r := range rs.ConvertRules {
+		for _, e := range r.Examples {
+			corpus = append(corpus, e.Input)
+		}
+	}
+	files, err := filepath.Glob("testdata/*.txt")
+	assert.NoError(t, err)
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		assert.NoError(t, err)
+		if !utf8.Valid(b) {
+			// SJISのファイルは対象外
+			continue
+		}
+		corpus = append(corpus, strings.Split(string(b), "\n")...)
+	}
+
+	custom, err := LoadRuleFile("testdata/rules/sample.yaml")
+	assert.NoError(t, err)
+
+	seed := int64(1)
+	tests := []struct {
+		desc string
+		opt  *ConvertOption
+	}{
+		{
+			desc: "正常系: 組み込みの変換ルールで索引を使っても同じ結果になりますわ",
+			opt:  &ConvertOption{Seed: &seed},
+		},
+		{
+			desc: "正常系: 変換の強さを変えても同じ結果になりますわ",
+			opt:  &ConvertOption{Seed: &seed, Level: LevelExtreme},
+		},
+		{
+			desc: "正常系: 独自の変換ルールを追加しても同じ結果になりますわ",
+			opt:  &ConvertOption{Seed: &seed, PrependRules: custom, DisableCategories: []Category{CategoryVulgar}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			indexed, err := NewConverter(tt.opt)
+			assert.NoError(err)
+			linear := *indexed
+			linear.forceLinearRuleMatch = true
+
+			for _, src := range corpus {
+				want, err := linear.Convert(src)
+				assert.NoError(err)
+				got, err := indexed.Convert(src)
+				assert.NoError(err)
+				assert.Equal(want, got, "src = %q", src)
+			}
+		})
+	}
+}
+
+func BenchmarkConvert(b *testing.B) {
+	opt := &ConvertOption{
+		DisableKutenToExclamation: true,
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Convert("これはハーブです", opt); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkNewConverterEveryTime(b *testing.B) {
+	opt := &ConvertOption{
+		DisableKutenToExclamation: true,
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c, err := NewConverter(opt)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := c.Convert("これはハーブです"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkConverterConvert(b *testing.B) {
+	c, err := NewConverter(&ConvertOption{
+		DisableKutenToExclamation: true,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Convert("これはハーブです"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkConvertSample1(b *testing.B) {
+	benchmarkConvertFile(b, "testdata/sample1.txt")
+}
+
+func BenchmarkConvertSample2(b *testing.B) {
+	benchmarkConvertFile(b, "testdata/sample2.txt")
+}
+
+// benchmarkConvertFile は path のテキスト全体を変換する時間とメモリの割り当てを計測する。
+func benchmarkConvertFile(b *testing.B, path string) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	seed := int64(1)
+	c, err := NewConverter(&ConvertOption{Seed: &seed})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Convert(string(src)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
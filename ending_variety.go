@@ -0,0 +1,227 @@
+package ojosama
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/ikawaha/kagome/v2/tokenizer"
+	"github.com/jiro4989/ojosama/internal/converter"
+	"github.com/jiro4989/ojosama/internal/feat"
+	"github.com/jiro4989/ojosama/internal/tokendata"
+)
+
+// EndingVarietyConfig は文末の語尾を言い換えて、同じ語尾が続かないようにする設定。
+//
+// 例えば「ハーブです。ハーブです。」を「おハーブですわ。おハーブですのよ。」のように変換する。
+// 言い換えるのは文末表現の変換ルールで変換した文末の語尾だけで、
+// 入力の時点でお嬢様言葉になっている語尾は言い換えない。
+type EndingVarietyConfig struct {
+	// 直近で使った語尾を覚えておく数。
+	// 覚えている語尾と同じ語尾になる場合は、同じ組の別の語尾に言い換える。
+	// 0 の場合は1つだけ覚えておく。
+	Memory int
+
+	// 互いに言い換えられる語尾の組。
+	// nil の場合は DefaultEndingAlternatives の組を使う。
+	Alternatives [][]string
+}
+
+// defaultEndingAlternatives は互いに言い換えられる語尾の組。
+var defaultEndingAlternatives = [][]string{
+	{"ですわ", "ですのよ", "ですこと", "ですわね"},
+	{"ますわ", "ますのよ", "ますわね"},
+}
+
+// defaultEndingVarietyMemory は Memory を指定しなかった時に覚えておく語尾の数。
+const defaultEndingVarietyMemory = 1
+
+var errInvalidEndingVariety = errors.New("invalid ending variety config")
+
+// DefaultEndingAlternatives は互いに言い換えられる語尾の組を返す。
+//
+// 返却値は書き換えても変換結果には影響しない。
+func DefaultEndingAlternatives() [][]string {
+	result := make([][]string, 0, len(defaultEndingAlternatives))
+	for _, v := range defaultEndingAlternatives {
+		result = append(result, copyStrings(v))
+	}
+	return result
+}
+
+// validate は語尾の言い換えの設定が正しいかを検証する。nil は問題ない。
+//
+// 語尾は空にできず、同じ語尾を複数の組に含めることはできない。
+func (c *EndingVarietyConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.Memory < 0 {
+		return fmt.Errorf("%w: memory must not be negative. memory = %d", errInvalidEndingVariety, c.Memory)
+	}
+	if c.Alternatives != nil && len(c.Alternatives) < 1 {
+		return fmt.Errorf("%w: alternatives must not be empty", errInvalidEndingVariety)
+	}
+	seen := make(map[string]struct{})
+	for _, group := range c.Alternatives {
+		if len(group) < 1 {
+			return fmt.Errorf("%w: alternatives must not contain an empty group", errInvalidEndingVariety)
+		}
+		for _, v := range group {
+			if v == "" {
+				return fmt.Errorf("%w: ending must not be empty", errInvalidEndingVariety)
+			}
+			if _, ok := seen[v]; ok {
+				return fmt.Errorf("%w: duplicated ending %q", errInvalidEndingVariety, v)
+			}
+			seen[v] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// endingVariety は文書全体で直近に使った語尾を覚えておき、語尾を言い換える。
+type endingVariety struct {
+	memory       int
+	alternatives [][]string
+	recent       []string // 直近で使った語尾。末尾ほど新しい
+}
+
+// newEndingVariety は cfg の設定で語尾を言い換える endingVariety を生成する。
+func newEndingVariety(cfg *EndingVarietyConfig) *endingVariety {
+	v := &endingVariety{
+		memory:       cfg.Memory,
+		alternatives: cfg.Alternatives,
+	}
+	if v.memory < 1 {
+		v.memory = defaultEndingVarietyMemory
+	}
+	if v.alternatives == nil {
+		v.alternatives = defaultEndingAlternatives
+	}
+	return v
+}
+
+// find は s の末尾に一致する最も長い語尾と、その語尾を含む組を返す。
+func (v *endingVariety) find(s string) (string, []string, bool) {
+	var ending string
+	var group []string
+	for _, g := range v.alternatives {
+		for _, e := range g {
+			if len(ending) < len(e) && strings.HasSuffix(s, e) {
+				ending = e
+				group = g
+			}
+		}
+	}
+	return ending, group, ending != ""
+}
+
+// isRecent は s が直近で使った語尾かどうかを判定する。
+func (v *endingVariety) isRecent(s string) bool {
+	for _, r := range v.recent {
+		if r == s {
+			return true
+		}
+	}
+	return false
+}
+
+// remember は直近で使った語尾として s を覚えておく。
+func (v *endingVariety) remember(s string) {
+	v.recent = append(v.recent, s)
+	if v.memory < len(v.recent) {
+		v.recent = v.recent[len(v.recent)-v.memory:]
+	}
+}
+
+// next は ending の代わりに使う語尾を group から選択する。
+//
+// ending を直近で使っていない場合はそのまま返す。
+// 直近で使っていない語尾が複数ある場合はランダムに選択し、
+// すべて使っている場合は最後に使った語尾以外から選択する。
+func (v *endingVariety) next(ending string, group []string, rnd *rand.Rand) string {
+	if !v.isRecent(ending) {
+		return ending
+	}
+
+	var candidates []string
+	for _, e := range group {
+		if !v.isRecent(e) {
+			candidates = append(candidates, e)
+		}
+	}
+	if len(candidates) < 1 {
+		last := v.recent[len(v.recent)-1]
+		for _, e := range group {
+			if e != last {
+				candidates = append(candidates, e)
+			}
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return ending
+	case 1:
+		return candidates[0]
+	}
+	return candidates[rnd.Intn(len(candidates))]
+}
+
+// varyEndings は文末表現の変換ルールで変換した文末の語尾を、
+// 直近で使った語尾と重ならないように言い換える。
+//
+// v は文書全体で直近に使った語尾を覚えておくため、変換をまたいで引き継ぐ。
+func (c *Converter) varyEndings(tokens []tokenizer.TokenData, spans []span, v *endingVariety, rnd *rand.Rand) {
+	for i := range spans {
+		sp := &spans[i]
+		if !c.isEndingSpan(sp) || !isSentenceEnd(tokens, sp) {
+			continue
+		}
+		ending, group, ok := v.find(sp.value)
+		if !ok {
+			continue
+		}
+		e := v.next(ending, group, rnd)
+		sp.value = strings.TrimSuffix(sp.value, ending) + e
+		v.remember(e)
+	}
+}
+
+// isEndingSpan は sp が文末表現の変換ルールで変換した区間かどうかを判定する。
+//
+// 形容詞文の変換ルールも文末に「ですわ」を付けるため、文末表現として扱う。
+func (c *Converter) isEndingSpan(sp *span) bool {
+	if sp.ruleIndex < 0 {
+		return false
+	}
+	var cat converter.Category
+	switch sp.ruleKind {
+	case RuleKindConvert:
+		cat = c.convertRules[sp.ruleIndex].Category
+	case RuleKindContinuousConditions:
+		cat = c.continuousConditionsConvertRules[sp.ruleIndex].Category
+	default:
+		return false
+	}
+	return cat == converter.CategoryEnding || cat == converter.CategoryAdjective
+}
+
+// isSentenceEnd は sp が文の終わりの区間かどうかを判定する。
+//
+// 区間の後ろに句点や感嘆符、疑問符、空白がある場合か、
+// 区間が文章の最後の場合に文の終わりとみなす。
+func isSentenceEnd(tokens []tokenizer.TokenData, sp *span) bool {
+	if sp.longNote != "" || sp.kutenToExclamation != "" {
+		return true
+	}
+	if len(tokens) <= sp.end {
+		return true
+	}
+	data := tokens[sp.end]
+	if tokendata.EqualsFeatures(data.Features, feat.Kuten) || strings.TrimSpace(data.Surface) == "" {
+		return true
+	}
+	return tokendata.ContainsString([]string{"！", "!", "？", "?"}, data.Surface)
+}
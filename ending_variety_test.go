@@ -0,0 +1,160 @@
+package ojosama
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertEndingVariety(t *testing.T) {
+	tests := []struct {
+		desc string
+		src  string
+		opt  ConvertOption
+		want string
+	}{
+		{
+			desc: "正常系: 設定しない場合は語尾を言い換えませんわ",
+			src:  "ハーブです。ハーブです。ハーブです。",
+			opt:  ConvertOption{},
+			want: "おハーブですわ。おハーブですわ。おハーブですわ。",
+		},
+		{
+			desc: "正常系: 言い換えの候補が2つの場合は交互に言い換えますわ",
+			src:  "ハーブです。ハーブです。ハーブです。",
+			opt: ConvertOption{
+				EndingVariety: &EndingVarietyConfig{
+					Alternatives: [][]string{{"ですわ", "ですのよ"}},
+				},
+			},
+			want: "おハーブですわ。おハーブですのよ。おハーブですわ。",
+		},
+		{
+			desc: "正常系: 言い換えの候補をすべて覚えている場合は最後に使った語尾以外にしますわ",
+			src:  "ハーブです。ハーブです。ハーブです。",
+			opt: ConvertOption{
+				EndingVariety: &EndingVarietyConfig{
+					Memory:       5,
+					Alternatives: [][]string{{"ですわ", "ですのよ"}},
+				},
+			},
+			want: "おハーブですわ。おハーブですのよ。おハーブですわ。",
+		},
+		{
+			desc: "正常系: 文の途中の語尾は言い換えませんわ",
+			src:  "ハーブですが、ハーブです。",
+			opt: ConvertOption{
+				EndingVariety: &EndingVarietyConfig{
+					Alternatives: [][]string{{"ですわ", "ですのよ"}},
+				},
+			},
+			want: "おハーブですわが、おハーブですわ。",
+		},
+		{
+			desc: "正常系: 入力の時点でお嬢様言葉の語尾は言い換えませんわ",
+			src:  "ハーブですわ。ハーブですわ。",
+			opt: ConvertOption{
+				EndingVariety: &EndingVarietyConfig{
+					Alternatives: [][]string{{"ですわ", "ですのよ"}},
+				},
+			},
+			want: "おハーブですわ。おハーブですわ。",
+		},
+		{
+			desc: "正常系: 形容詞文の語尾も言い換えますわ",
+			src:  "ハーブは美しい。ハーブは美しい。",
+			opt: ConvertOption{
+				EndingVariety: &EndingVarietyConfig{
+					Alternatives: [][]string{{"ですわ", "ですこと"}},
+				},
+			},
+			want: "おハーブは美しいですわ。おハーブは美しいですこと。",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// 乱数に依存しないことを、シード値を変えて何度か確認する
+			for i := int64(0); i < 10; i++ {
+				seed := i
+				opt := tt.opt
+				opt.Seed = &seed
+				opt.DisableKutenToExclamation = true
+				got, err := Convert(tt.src, &opt)
+				assert.NoError(err)
+				assert.Equal(tt.want, got)
+			}
+		})
+	}
+}
+
+func TestConvertEndingVarietyMemory(t *testing.T) {
+	src := "ハーブです。ハーブです。ハーブです。ハーブです。ハーブです。ハーブです。ハーブです。ハーブです。"
+	endingRe := regexp.MustCompile(`(ですわね|ですわ|ですのよ|ですこと)。`)
+
+	for _, memory := range []int{1, 2, 3} {
+		for i := int64(0); i < 20; i++ {
+			seed := i
+			got, err := Convert(src, &ConvertOption{
+				Seed:                      &seed,
+				DisableKutenToExclamation: true,
+				EndingVariety:             &EndingVarietyConfig{Memory: memory},
+			})
+			assert.NoError(t, err)
+
+			// 覚えている数の直近の語尾とは重なりませんわ
+			var endings []string
+			for _, m := range endingRe.FindAllStringSubmatch(got, -1) {
+				endings = append(endings, m[1])
+			}
+			assert.Len(t, endings, 8, got)
+			for j := range endings {
+				for k := j - memory; k < j; k++ {
+					if 0 <= k {
+						assert.NotEqual(t, endings[k], endings[j], "memory=%d: %s", memory, got)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestConvertInvalidEndingVariety(t *testing.T) {
+	tests := []struct {
+		desc string
+		cfg  *EndingVarietyConfig
+	}{
+		{
+			desc: "異常系: 負の数はエラーですわ",
+			cfg:  &EndingVarietyConfig{Memory: -1},
+		},
+		{
+			desc: "異常系: 空の組の一覧はエラーですわ",
+			cfg:  &EndingVarietyConfig{Alternatives: [][]string{}},
+		},
+		{
+			desc: "異常系: 空の語尾はエラーですわ",
+			cfg:  &EndingVarietyConfig{Alternatives: [][]string{{"ですわ", ""}}},
+		},
+		{
+			desc: "異常系: 複数の組に含まれる語尾はエラーですわ",
+			cfg:  &EndingVarietyConfig{Alternatives: [][]string{{"ですわ", "ですのよ"}, {"ですわ", "ですこと"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			opt := &ConvertOption{EndingVariety: tt.cfg}
+			_, err := Convert("ハーブです。", opt)
+			assert.ErrorIs(err, errInvalidEndingVariety)
+
+			_, err = NewConverter(opt)
+			assert.ErrorIs(err, errInvalidEndingVariety)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package ojosama
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// 変換で返すエラー。 errors.Is で判定できる。
+var (
+	// ErrInvalidUTF8 は変換元のテキストに UTF-8 として不正なバイト列が含まれている時のエラー。
+	ErrInvalidUTF8 = errors.New("invalid utf-8 sequence")
+
+	// ErrNULByte は変換元のテキストに NUL 文字が含まれている時のエラー。
+	ErrNULByte = errors.New("nul byte")
+
+	// ErrInputTooLarge は変換元のテキストが ConvertOption の MaxInputSize を超えた時のエラー。
+	// 超えた大きさは InputTooLargeError で取得できる。
+	ErrInputTooLarge = errors.New("input too large")
+
+	// ErrTokenizerInit は形態素解析器の初期化に失敗した時のエラー。
+	ErrTokenizerInit = errors.New("failed to initialize tokenizer")
+)
+
+// InvalidInputError は変換元のテキストに変換できない文字が含まれている時のエラー。
+//
+// Err は ErrInvalidUTF8 か ErrNULByte で、 errors.Is で判定できる。
+type InvalidInputError struct {
+	Offset int   // 変換できない文字の位置（先頭からのバイト数）
+	Err    error // 変換できない理由
+}
+
+func (e *InvalidInputError) Error() string {
+	return fmt.Sprintf("invalid input at byte offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *InvalidInputError) Unwrap() error {
+	return e.Err
+}
+
+// InputTooLargeError は変換元のテキストが ConvertOption の MaxInputSize を超えた時のエラー。
+//
+// errors.Is で ErrInputTooLarge と判定できる。
+type InputTooLargeError struct {
+	Size int // 変換元のテキストの大きさ（バイト単位）。逐次変換の場合は超えた時点までに読み込んだ大きさ
+	Max  int // 変換できるテキストの最大の大きさ（バイト単位）
+}
+
+func (e *InputTooLargeError) Error() string {
+	return fmt.Sprintf("%v: %d bytes exceeds the limit of %d bytes", ErrInputTooLarge, e.Size, e.Max)
+}
+
+func (e *InputTooLargeError) Is(target error) bool {
+	return target == ErrInputTooLarge
+}
+
+// checkInputSize は大きさ size のテキストを opt の設定で変換できるかを検証する。
+func checkInputSize(opt *ConvertOption, size int) error {
+	if opt == nil || opt.MaxInputSize < 1 || size <= opt.MaxInputSize {
+		return nil
+	}
+	return &InputTooLargeError{Size: size, Max: opt.MaxInputSize}
+}
+
+// validateInput は s に変換できない文字が含まれていないかを検証する。
+//
+// offset は s より前に変換したテキストの大きさで、エラーの位置に加算する。
+func validateInput(s string, offset int) error {
+	for i := 0; i < len(s); {
+		if s[i] == 0 {
+			return &InvalidInputError{Offset: offset + i, Err: ErrNULByte}
+		}
+		if s[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			return &InvalidInputError{Offset: offset + i, Err: ErrInvalidUTF8}
+		}
+		i += size
+	}
+	return nil
+}
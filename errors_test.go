@@ -0,0 +1,95 @@
+package ojosama
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertInvalidInput(t *testing.T) {
+	tests := []struct {
+		desc       string
+		src        string
+		wantErr    error
+		wantOffset int
+	}{
+		{
+			desc:    "正常系: 変換できる文字だけの場合はエラーになりませんわ",
+			src:     "ハーブです。",
+			wantErr: nil,
+		},
+		{
+			desc:       "異常系: UTF-8 として不正なバイト列を含む場合はエラーですわ",
+			src:        "ハーブ\xffです。",
+			wantErr:    ErrInvalidUTF8,
+			wantOffset: len("ハーブ"),
+		},
+		{
+			desc:       "異常系: 途中で切れている文字を含む場合はエラーですわ",
+			src:        "ハーブです。" + string([]byte("ハ")[:2]),
+			wantErr:    ErrInvalidUTF8,
+			wantOffset: len("ハーブです。"),
+		},
+		{
+			desc:       "異常系: NUL 文字を含む場合はエラーですわ",
+			src:        "ハーブ\x00です。",
+			wantErr:    ErrNULByte,
+			wantOffset: len("ハーブ"),
+		},
+	}
+
+	c, err := NewConverter(streamTestOption)
+	assert.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// どの変換の方法でも同じエラーになりますわ
+			_, err1 := Convert(tt.src, streamTestOption)
+			_, err2 := c.Convert(tt.src)
+			_, err3 := ConvertSegments(tt.src, streamTestOption)
+			_, _, err4 := ConvertWithTrace(tt.src, streamTestOption)
+			_, err5 := io.ReadAll(c.NewReader(strings.NewReader(tt.src)))
+
+			for _, err := range []error{err1, err2, err3, err4, err5} {
+				if tt.wantErr == nil {
+					assert.NoError(err)
+					continue
+				}
+				assert.ErrorIs(err, tt.wantErr)
+				var e *InvalidInputError
+				if assert.True(errors.As(err, &e)) {
+					assert.Equal(tt.wantOffset, e.Offset)
+				}
+			}
+		})
+	}
+}
+
+func TestConverterNewReaderInvalidInputOffset(t *testing.T) {
+	assert := assert.New(t)
+
+	c, err := NewConverter(streamTestOption)
+	assert.NoError(err)
+
+	// 逐次変換でも入力の先頭からの位置を返しますわ
+	valid := strings.Repeat("ハーブです。\n", 1000)
+	_, err = io.ReadAll(c.NewReader(strings.NewReader(valid + "\xff")))
+	assert.ErrorIs(err, ErrInvalidUTF8)
+	var e *InvalidInputError
+	if assert.True(errors.As(err, &e)) {
+		assert.Equal(len(valid), e.Offset)
+	}
+}
+
+func TestInputTooLargeErrorIs(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := Convert("ハーブです。", &ConvertOption{MaxInputSize: 1})
+	assert.ErrorIs(err, ErrInputTooLarge)
+	assert.NotErrorIs(err, ErrInvalidUTF8)
+}
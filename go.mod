@@ -3,13 +3,10 @@ module github.com/jiro4989/ojosama
 go 1.25.0
 
 require (
+	github.com/ikawaha/kagome-dict v1.1.7
 	github.com/ikawaha/kagome-dict/ipa v1.2.6
 	github.com/ikawaha/kagome/v2 v2.11.0
 	github.com/stretchr/testify v1.12.0
 	golang.org/x/text v0.41.0
-)
-
-require (
-	github.com/ikawaha/kagome-dict v1.1.7 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
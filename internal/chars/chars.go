@@ -17,29 +17,29 @@ type TestMode struct {
 }
 
 const (
-	styleTypeUnknown StyleType = iota
-	styleTypeFullWidth
-	styleTypeHalfWidth
-	styleTypeEmoji
-	styleTypeDoubleEmoji // !!
-
-	meaningTypeUnknown = iota
-	meaningTypeExcl    // !
-	meaningTypeQues    // ?
-	meaningTypeEQ      // !?
+	StyleTypeUnknown StyleType = iota
+	StyleTypeFullWidth
+	StyleTypeHalfWidth
+	StyleTypeEmoji
+	StyleTypeDoubleEmoji // !!
+
+	MeaningTypeUnknown = iota
+	MeaningTypeExcl    // !
+	MeaningTypeQues    // ?
+	MeaningTypeEQ      // !?
 )
 
 var (
 	eqMarks = []ExclamationQuestionMark{
-		newExcl("！", styleTypeFullWidth),
-		newExcl("!", styleTypeHalfWidth),
-		newExcl("❗", styleTypeEmoji),
-		newExcl("‼", styleTypeDoubleEmoji),
-		newQues("？", styleTypeFullWidth),
-		newQues("?", styleTypeHalfWidth),
-		newQues("❓", styleTypeEmoji),
-		newEQ("!?", styleTypeHalfWidth),
-		newEQ("⁉", styleTypeEmoji),
+		newExcl("！", StyleTypeFullWidth),
+		newExcl("!", StyleTypeHalfWidth),
+		newExcl("❗", StyleTypeEmoji),
+		newExcl("‼", StyleTypeDoubleEmoji),
+		newQues("？", StyleTypeFullWidth),
+		newQues("?", StyleTypeHalfWidth),
+		newQues("❓", StyleTypeEmoji),
+		newEQ("!?", StyleTypeHalfWidth),
+		newEQ("⁉", StyleTypeEmoji),
 	}
 )
 
@@ -47,7 +47,7 @@ func newExcl(v string, t StyleType) ExclamationQuestionMark {
 	return ExclamationQuestionMark{
 		Value:   v,
 		Style:   t,
-		Meaning: meaningTypeExcl,
+		Meaning: MeaningTypeExcl,
 	}
 }
 
@@ -55,7 +55,7 @@ func newQues(v string, t StyleType) ExclamationQuestionMark {
 	return ExclamationQuestionMark{
 		Value:   v,
 		Style:   t,
-		Meaning: meaningTypeQues,
+		Meaning: MeaningTypeQues,
 	}
 }
 
@@ -63,7 +63,7 @@ func newEQ(v string, t StyleType) ExclamationQuestionMark {
 	return ExclamationQuestionMark{
 		Value:   v,
 		Style:   t,
-		Meaning: meaningTypeEQ,
+		Meaning: MeaningTypeEQ,
 	}
 }
 
@@ -76,7 +76,10 @@ func IsExclamationQuestionMark(s string) (bool, *ExclamationQuestionMark) {
 	return false, nil
 }
 
-func SampleExclamationQuestionByValue(v string, t *TestMode) *ExclamationQuestionMark {
+// SampleExclamationQuestionByValue は v と同じ意味の感嘆符・疑問符をランダムに1つ返す。
+//
+// rnd が nil の場合はグローバルな乱数を使う。
+func SampleExclamationQuestionByValue(v string, rnd *rand.Rand, t *TestMode) *ExclamationQuestionMark {
 	ok, got := IsExclamationQuestionMark(v)
 	if !ok {
 		return nil
@@ -97,8 +100,12 @@ func SampleExclamationQuestionByValue(v string, t *TestMode) *ExclamationQuestio
 		// テスト用のパラメータがあるときは決め打ちで返す
 		return &s[t.Pos]
 	}
-	rand.Shuffle(len(s), func(i, j int) { s[i], s[j] = s[j], s[i] })
-	return &s[0]
+	// 候補のスライスは並び替えずに添字をランダムに選択する
+	intn := rand.Intn
+	if rnd != nil {
+		intn = rnd.Intn
+	}
+	return &s[intn(len(s))]
 }
 
 func FindExclamationQuestionByStyleAndMeaning(s StyleType, m MeaningType) *ExclamationQuestionMark {
@@ -120,3 +127,28 @@ func FindExclamationQuestionByStyleAndMeaning(s StyleType, m MeaningType) *Excla
 
 	return nil
 }
+
+// styleFallbacks は指定したスタイルに同じ意味の文字が無い時に代わりに使うスタイル。
+//
+// 例えば二重の絵文字には疑問符が無いため、絵文字の疑問符を使う。
+var styleFallbacks = map[StyleType]StyleType{
+	StyleTypeDoubleEmoji: StyleTypeEmoji,
+	StyleTypeFullWidth:   StyleTypeHalfWidth,
+}
+
+// FindExclamationQuestionByStyleAndMeaningWithFallback は
+// FindExclamationQuestionByStyleAndMeaning と同じく s と m に一致する文字を返す。
+//
+// 一致する文字が無い場合は、代わりのスタイルで同じ意味の文字を探す。
+func FindExclamationQuestionByStyleAndMeaningWithFallback(s StyleType, m MeaningType) *ExclamationQuestionMark {
+	for {
+		if got := FindExclamationQuestionByStyleAndMeaning(s, m); got != nil {
+			return got
+		}
+		next, ok := styleFallbacks[s]
+		if !ok {
+			return nil
+		}
+		s = next
+	}
+}
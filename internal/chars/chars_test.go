@@ -17,13 +17,13 @@ func TestIsExclamationQuestionMark(t *testing.T) {
 			desc:   "正常系: ！とはマッチいたしますわ",
 			s:      "！",
 			wantOK: true,
-			wantEQ: newExcl("！", styleTypeFullWidth),
+			wantEQ: newExcl("！", StyleTypeFullWidth),
 		},
 		{
 			desc:   "正常系: ❓とはマッチいたしますわ",
 			s:      "❓",
 			wantOK: true,
-			wantEQ: newQues("❓", styleTypeEmoji),
+			wantEQ: newQues("❓", StyleTypeEmoji),
 		},
 		{
 			desc:   "正常系: 漆とはマッチいたしませんわ",
@@ -57,13 +57,13 @@ func TestSampleExclamationQuestionByValue(t *testing.T) {
 			desc: "正常系: ！とはマッチいたしますわ",
 			v:    "！",
 			t:    &TestMode{Pos: 0},
-			want: newExcl("！", styleTypeFullWidth),
+			want: newExcl("！", StyleTypeFullWidth),
 		},
 		{
 			desc: "正常系: ❓とはマッチいたしますわ",
 			v:    "❓",
 			t:    &TestMode{Pos: 2},
-			want: newQues("❓", styleTypeEmoji),
+			want: newQues("❓", StyleTypeEmoji),
 		},
 		{
 			desc:    "正常系: 菫とはマッチいたしませんわ",
@@ -77,7 +77,7 @@ func TestSampleExclamationQuestionByValue(t *testing.T) {
 		t.Run(tt.desc, func(t *testing.T) {
 			assert := assert.New(t)
 
-			got := SampleExclamationQuestionByValue(tt.v, tt.t)
+			got := SampleExclamationQuestionByValue(tt.v, nil, tt.t)
 			if tt.wantNil {
 				assert.Nil(got)
 				return
@@ -97,26 +97,26 @@ func TestFindExclamationQuestionByStyleAndMeaning(t *testing.T) {
 	}{
 		{
 			desc: "正常系: ❗を指定いたしますわ",
-			s:    styleTypeEmoji,
-			m:    meaningTypeExcl,
-			want: newExcl("❗", styleTypeEmoji),
+			s:    StyleTypeEmoji,
+			m:    MeaningTypeExcl,
+			want: newExcl("❗", StyleTypeEmoji),
 		},
 		{
 			desc: "正常系: ？を指定いたしますわ",
-			s:    styleTypeFullWidth,
-			m:    meaningTypeQues,
-			want: newQues("？", styleTypeFullWidth),
+			s:    StyleTypeFullWidth,
+			m:    MeaningTypeQues,
+			want: newQues("？", StyleTypeFullWidth),
 		},
 		{
 			desc:    "正常系: 不明な要素の場合は何もお返しいたしませんわ",
-			s:       styleTypeUnknown,
-			m:       meaningTypeExcl,
+			s:       StyleTypeUnknown,
+			m:       MeaningTypeExcl,
 			wantNil: true,
 		},
 		{
 			desc:    "正常系: 不明な要素の場合は何もお返しいたしませんわ",
-			s:       styleTypeFullWidth,
-			m:       meaningTypeUnknown,
+			s:       StyleTypeFullWidth,
+			m:       MeaningTypeUnknown,
 			wantNil: true,
 		},
 	}
@@ -134,3 +134,51 @@ func TestFindExclamationQuestionByStyleAndMeaning(t *testing.T) {
 		})
 	}
 }
+
+func TestFindExclamationQuestionByStyleAndMeaningWithFallback(t *testing.T) {
+	tests := []struct {
+		desc    string
+		s       StyleType
+		m       MeaningType
+		want    ExclamationQuestionMark
+		wantNil bool
+	}{
+		{
+			desc: "正常系: 一致する文字があればそのままお返しいたしますわ",
+			s:    StyleTypeDoubleEmoji,
+			m:    MeaningTypeExcl,
+			want: newExcl("‼", StyleTypeDoubleEmoji),
+		},
+		{
+			desc: "正常系: 二重の絵文字の疑問符は絵文字で代用いたしますわ",
+			s:    StyleTypeDoubleEmoji,
+			m:    MeaningTypeQues,
+			want: newQues("❓", StyleTypeEmoji),
+		},
+		{
+			desc: "正常系: 全角の!?は半角で代用いたしますわ",
+			s:    StyleTypeFullWidth,
+			m:    MeaningTypeEQ,
+			want: newEQ("!?", StyleTypeHalfWidth),
+		},
+		{
+			desc:    "正常系: 不明な要素の場合は何もお返しいたしませんわ",
+			s:       StyleTypeUnknown,
+			m:       MeaningTypeExcl,
+			wantNil: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got := FindExclamationQuestionByStyleAndMeaningWithFallback(tt.s, tt.m)
+			if tt.wantNil {
+				assert.Nil(got)
+				return
+			}
+			assert.Equal(&tt.want, got)
+		})
+	}
+}
@@ -116,3 +116,42 @@ func (c *ConvertConditions) MatchAnyTokenData(data tokenizer.TokenData) bool {
 	}
 	return false
 }
+
+// mayOverlap は c と o の両方に一致するTokenがありうる時に true を返す。
+//
+// 文字列同士は一致するか、正規表現と文字列は正規表現が文字列にマッチする場合に
+// 重なりうるとみなす。正規表現同士は判定できないため、重なりうるとみなす。
+func (c *ConvertCondition) mayOverlap(o ConvertCondition) bool {
+	if 0 < len(c.Features) && 0 < len(o.Features) && !tokendata.EqualsFeatures(c.Features, o.Features) {
+		return false
+	}
+	return mayOverlapString(c.Surface, c.SurfaceRe, o.Surface, o.SurfaceRe) &&
+		mayOverlapString(c.Reading, c.ReadingRe, o.Reading, o.ReadingRe) &&
+		mayOverlapString(c.BaseForm, c.BaseFormRe, o.BaseForm, o.BaseFormRe)
+}
+
+// mayOverlapString は文字列と正規表現の条件 a, b の両方に一致する文字列がありうる時に true を返す。
+func mayOverlapString(a string, aRe *regexp.Regexp, b string, bRe *regexp.Regexp) bool {
+	if a != "" && b != "" && a != b {
+		return false
+	}
+	if isNotNilAndDoesntMatchString(aRe, b) && b != "" {
+		return false
+	}
+	if isNotNilAndDoesntMatchString(bRe, a) && a != "" {
+		return false
+	}
+	return true
+}
+
+// mayOverlap は c と o のいずれかの条件同士が、同じTokenに一致しうる時に true を返す。
+func (c *ConvertConditions) mayOverlap(o ConvertConditions) bool {
+	for _, a := range *c {
+		for _, b := range o {
+			if a.mayOverlap(b) {
+				return true
+			}
+		}
+	}
+	return false
+}
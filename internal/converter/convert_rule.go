@@ -1,6 +1,7 @@
 package converter
 
 import (
+	"math/rand"
 	"regexp"
 
 	"github.com/ikawaha/kagome/v2/tokenizer"
@@ -19,14 +20,31 @@ type ConvertRule struct {
 	DisablePrefix                bool              // 「お」を手前に付与しない
 	EnableKutenToExclamation     bool              // 直後に句点が来たとき確率で！に変換する
 	Value                        string            // この文字列に置換する
+	Category                     Category          // 変換ルールの分類
+	ID                           string            // 変換ルールを一意に識別するID。「分類.名前」の形式
+	Description                  string            // 変換ルールの説明
+	Examples                     []Example         // 変換例。単体テストで実際に変換して確認する
 }
 
+// Example は変換ルールの変換例。
+type Example struct {
+	Input string // 変換前の文字列
+	Want  string // 変換後の文字列
+}
+
+// newRule は surface を value に変換する変換ルールを生成する。
+//
+// 変換例は単語単体の変換になるため、前後の単語で変換が変わる変換ルールには
+// example などで文章の変換例を指定すること。
 func newRule(features []string, surface, value string) ConvertRule {
 	return ConvertRule{
 		Conditions: ConvertConditions{
 			newCond(features, surface),
 		},
-		Value: value,
+		Value:       value,
+		ID:          surface,
+		Description: "「" + surface + "」を「" + value + "」に変換する",
+		Examples:    []Example{{Input: surface, Want: value}},
 	}
 }
 
@@ -55,21 +73,131 @@ func (c ConvertRule) disablePrefix(v bool) ConvertRule {
 	return c
 }
 
+// example は変換例を置き換える。
+//
+// 単語単体では意図した品詞に解析されない場合に、文章の変換例を指定するために使う。
+func (c ConvertRule) example(input, want string) ConvertRule {
+	c.Examples = []Example{{Input: input, Want: want}}
+	return c
+}
+
+// categorize は rules の分類をすべて cat にして返す。
+//
+// ID には分類の名前を前置する。
+func categorize(cat Category, rules []ConvertRule) []ConvertRule {
+	for i := range rules {
+		rules[i].Category = cat
+		rules[i].ID = cat.String() + "." + rules[i].ID
+	}
+	return rules
+}
+
+// categorizeContinuous は rules の分類をすべて cat にして返す。
+//
+// ID には分類の名前を前置する。
+func categorizeContinuous(cat Category, rules []ContinuousConditionsConvertRule) []ContinuousConditionsConvertRule {
+	for i := range rules {
+		rules[i].Category = cat
+		rules[i].ID = cat.String() + "." + rules[i].ID
+	}
+	return rules
+}
+
+// joinRules は分類ごとに定義した変換ルールを定義順に連結する。
+func joinRules[T any](rules ...[]T) []T {
+	var result []T
+	for _, r := range rules {
+		result = append(result, r...)
+	}
+	return result
+}
+
 // ContinuousConditionsConvertRule は連続する条件がすべてマッチしたときに変換するルール。
+//
+// 直後に句点が来たときは、ルールによらず常に確率で！に変換する。
 type ContinuousConditionsConvertRule struct {
-	Conditions               ConvertConditions
-	AppendLongNote           bool
-	EnableKutenToExclamation bool
-	Value                    string
+	Conditions     ConvertConditions
+	AppendLongNote bool
+	Value          string
+	Category       Category  // 変換ルールの分類
+	ID             string    // 変換ルールを一意に識別するID。「分類.名前」の形式
+	Description    string    // 変換ルールの説明
+	Examples       []Example // 変換例。単体テストで実際に変換して確認する
 }
 
 // SentenceEndingParticleConvertRule は「名詞」＋「動詞」＋「終助詞」の組み合わせによる変換ルール。
 type SentenceEndingParticleConvertRule struct {
-	Conditions1            ConvertConditions                 // 一番最初に評価されるルール
-	Conditions2            ConvertConditions                 // 二番目に評価されるルール
-	AuxiliaryVerb          ConvertConditions                 // 助動詞。マッチしなくても次にすすむ
-	SentenceEndingParticle map[MeaningType]ConvertConditions // 終助詞
-	Value                  map[MeaningType][]string
+	Conditions1            ConvertConditions                // 一番最初に評価されるルール
+	Conditions2            ConvertConditions                // 二番目に評価されるルール
+	AuxiliaryVerb          ConvertConditions                // 助動詞。マッチしなくても次にすすむ
+	SentenceEndingParticle SentenceEndingParticleConditions // 終助詞の意味分類ごとの条件
+	Value                  map[MeaningType][]string         // 意味分類ごとの変換候補
+	ValueWeights           map[MeaningType][]int            // 変換候補と同じ並びの重み。無い場合は同じ確率で選択する
+	Category               Category                         // 変換ルールの分類
+	ID                     string                           // 変換ルールを一意に識別するID。「分類.名前」の形式
+	Description            string                           // 変換ルールの説明
+	Examples               []Example                        // 変換例。単体テストで実際に変換して確認する
+}
+
+// Category は変換ルールの分類。
+//
+// 変換の強さや機能の ON/OFF で、分類ごとに変換ルールを有効・無効にするために使う。
+type Category int
+
+const (
+	CategoryOther         Category = iota // その他
+	CategoryPronoun                       // 人称代名詞
+	CategoryDemonstrative                 // こそあど言葉
+	CategoryEnding                        // 文末表現
+	CategoryAdjective                     // 形容詞文
+	CategoryInterjection                  // 感動詞
+	CategoryVulgar                        // 下品な言葉
+	CategoryName                          // 固有名詞
+	CategoryExclusion                     // 変換しない単語
+)
+
+var categoryNames = map[Category]string{
+	CategoryOther:         "other",
+	CategoryPronoun:       "pronoun",
+	CategoryDemonstrative: "demonstrative",
+	CategoryEnding:        "ending",
+	CategoryAdjective:     "adjective",
+	CategoryInterjection:  "interjection",
+	CategoryVulgar:        "vulgar",
+	CategoryName:          "name",
+	CategoryExclusion:     "exclusion",
+}
+
+// String は分類の名前を返す。
+func (c Category) String() string {
+	if s, ok := categoryNames[c]; ok {
+		return s
+	}
+	return categoryNames[CategoryOther]
+}
+
+// CategoryNames はすべての分類の名前を定義順に返す。
+func CategoryNames() []string {
+	var result []string
+	for c := Category(0); ; c++ {
+		s, ok := categoryNames[c]
+		if !ok {
+			return result
+		}
+		result = append(result, s)
+	}
+}
+
+// ParseCategory は名前に対応する分類を返す。
+//
+// 名前が不明な場合は false を返す。
+func ParseCategory(s string) (Category, bool) {
+	for k, v := range categoryNames {
+		if v == s {
+			return k, true
+		}
+	}
+	return CategoryOther, false
 }
 
 // MeaningType は言葉の意味分類。
@@ -83,6 +211,34 @@ const (
 	meaningTypeCoercion                // 強制
 )
 
+var meaningTypeNames = map[MeaningType]string{
+	meaningTypeUnknown:     "unknown",
+	meaningTypeHope:        "hope",
+	meaningTypePoem:        "poem",
+	meaningTypeProhibition: "prohibition",
+	meaningTypeCoercion:    "coercion",
+}
+
+// String は意味分類の名前を返す。
+func (m MeaningType) String() string {
+	if s, ok := meaningTypeNames[m]; ok {
+		return s
+	}
+	return meaningTypeNames[meaningTypeUnknown]
+}
+
+// ParseMeaningType は名前に対応する意味分類を返す。
+//
+// 名前が不明な場合は false を返す。
+func ParseMeaningType(s string) (MeaningType, bool) {
+	for k, v := range meaningTypeNames {
+		if k != meaningTypeUnknown && v == s {
+			return k, true
+		}
+	}
+	return meaningTypeUnknown, false
+}
+
 var (
 	SentenceEndingParticleConvertRules = []SentenceEndingParticleConvertRule{
 		{
@@ -94,22 +250,34 @@ var (
 				{Features: pos.VerbIndependence, BaseForm: "する"},
 				{Features: pos.VerbIndependence, BaseForm: "やる"},
 			},
-			SentenceEndingParticle: map[MeaningType]ConvertConditions{
-				meaningTypeHope: {
-					newCondSentenceEndingParticle("ぜ"),
-					newCondSentenceEndingParticle("よ"),
-					newCondSentenceEndingParticle("べ"),
+			SentenceEndingParticle: SentenceEndingParticleConditions{
+				{
+					MeaningType: meaningTypeHope,
+					Conditions: ConvertConditions{
+						newCondSentenceEndingParticle("ぜ"),
+						newCondSentenceEndingParticle("よ"),
+						newCondSentenceEndingParticle("べ"),
+					},
 				},
-				meaningTypePoem: {
-					// これだけ特殊
-					newCond([]string{"助詞", "副助詞／並立助詞／終助詞"}, "か"),
+				{
+					MeaningType: meaningTypePoem,
+					Conditions: ConvertConditions{
+						// これだけ特殊
+						newCond([]string{"助詞", "副助詞／並立助詞／終助詞"}, "か"),
+					},
 				},
-				meaningTypeProhibition: {
-					newCondSentenceEndingParticle("な"),
+				{
+					MeaningType: meaningTypeProhibition,
+					Conditions: ConvertConditions{
+						newCondSentenceEndingParticle("な"),
+					},
 				},
-				meaningTypeCoercion: {
-					newCondSentenceEndingParticle("ぞ"),
-					newCondSentenceEndingParticle("の"),
+				{
+					MeaningType: meaningTypeCoercion,
+					Conditions: ConvertConditions{
+						newCondSentenceEndingParticle("ぞ"),
+						newCondSentenceEndingParticle("の"),
+					},
 				},
 			},
 			AuxiliaryVerb: ConvertConditions{
@@ -118,6 +286,7 @@ var (
 			Value: map[MeaningType][]string{
 				meaningTypeHope: {
 					"をいたしませんこと",
+					"をいたしましょう",
 				},
 				meaningTypePoem: {
 					"をいたしますわ",
@@ -129,6 +298,14 @@ var (
 					"をいたしますわよ",
 				},
 			},
+			Category:    CategoryEnding,
+			ID:          "ending.名詞+動詞+終助詞",
+			Description: "名詞＋する（やる）＋終助詞を、終助詞の意味に合わせた丁寧な言い回しに変換する",
+			Examples: []Example{
+				{Input: "野球しようぜ", Want: "お野球をいたしませんこと"},
+				{Input: "野球するな", Want: "お野球をしてはいけませんわ"},
+				{Input: "週末は一緒に野球しようぜ。", Want: "週末は一緒にお野球をいたしませんこと。"},
+			},
 		},
 	}
 
@@ -139,435 +316,839 @@ var (
 	//
 	// 例えば「壱百満天原サロメ」や「横断歩道」のように、複数のTokenがこの順序で連続
 	// して初めて1つの意味になるような条件を定義する。
-	ContinuousConditionsConvertRules = []ContinuousConditionsConvertRule{
-		{
-			Value:      "壱百満天原サロメ",
-			Conditions: newConds([]string{"壱", "百", "満天", "原", "サロメ"}),
-		},
+	ContinuousConditionsConvertRules = joinRules(
+		categorizeContinuous(CategoryName, []ContinuousConditionsConvertRule{
+			{
+				Value:       "壱百満天原サロメ",
+				Conditions:  newConds([]string{"壱", "百", "満天", "原", "サロメ"}),
+				ID:          "壱百満天原サロメ",
+				Description: "「壱百満天原サロメ」を1つの単語として扱う",
+				Examples: []Example{
+					{Input: "壱百満天原サロメです", Want: "壱百満天原サロメですわ"},
+					{Input: "昨日、壱百満天原サロメの配信を見た。", Want: "昨日、壱百満天原サロメの配信を見たわ。"},
+				},
+			},
 
-		{
-			Value:      "壱百満天原",
-			Conditions: newConds([]string{"壱", "百", "満天", "原"}),
-		},
+			{
+				Value:       "壱百満天原",
+				Conditions:  newConds([]string{"壱", "百", "満天", "原"}),
+				ID:          "壱百満天原",
+				Description: "「壱百満天原」を1つの単語として扱う",
+				Examples: []Example{
+					{Input: "壱百満天原です", Want: "壱百満天原ですわ"},
+					{Input: "壱百満天原という名字は珍しい。", Want: "壱百満天原というお名字は珍しいですわ。"},
+				},
+			},
 
-		{
-			Value:      "壱百満点",
-			Conditions: newConds([]string{"壱", "百", "満点"}),
-		},
+			{
+				Value:       "壱百満点",
+				Conditions:  newConds([]string{"壱", "百", "満点"}),
+				ID:          "壱百満点",
+				Description: "「壱百満点」を1つの単語として扱う",
+				Examples: []Example{
+					{Input: "壱百満点です", Want: "壱百満点ですわ"},
+					{Input: "テストは壱百満点だった。", Want: "テストは壱百満点だったわ。"},
+				},
+			},
+		}),
+		categorizeContinuous(CategoryDemonstrative, []ContinuousConditionsConvertRule{
+			// 「あれください」の「あれ」は動詞の「あれる」として解析されるが、
+			// 変換後の「あれくださいまし」は代名詞の「あれ」として解析されるため、ここで変換しておく
+			{
+				Value: "あちらくださいまし",
+				Conditions: ConvertConditions{
+					newCond(pos.VerbIndependence, "あれ"),
+					newCond(pos.VerbNotIndependence, "ください"),
+				},
+				ID:          "あれ+ください",
+				Description: "「あれください」を「あちらくださいまし」に変換する",
+				Examples: []Example{
+					{Input: "あれください", Want: "あちらくださいまし"},
+					{Input: "すみません、あれください。", Want: "すみません、あちらくださいまし。"},
+				},
+			},
+		}),
+		categorizeContinuous(CategoryEnding, []ContinuousConditionsConvertRule{
+			{
+				Value:          "いたしますわ",
+				AppendLongNote: true,
+				Conditions: ConvertConditions{
+					newCond([]string{"動詞", "自立"}, "し"),
+					newCond([]string{"助動詞"}, "ます"),
+				},
+				ID:          "し+ます",
+				Description: "「します」を「いたしますわ」に変換する",
+				Examples: []Example{
+					{Input: "ハーブを栽培します", Want: "おハーブを栽培いたしますわ"},
+					{Input: "明日から毎朝ハーブに水やりをします。", Want: "明日から毎朝おハーブに水やりをいたしますわ。"},
+				},
+			},
 
-		{
-			Value:          "いたしますわ",
-			AppendLongNote: true,
-			Conditions: ConvertConditions{
-				newCond([]string{"動詞", "自立"}, "し"),
-				newCond([]string{"助動詞"}, "ます"),
+			{
+				Value: "ですので",
+				Conditions: ConvertConditions{
+					newCond([]string{"助動詞"}, "だ"),
+					newCond([]string{"助詞", "接続助詞"}, "から"),
+				},
+				ID:          "だ+から",
+				Description: "「だから」を「ですので」に変換する",
+				Examples: []Example{
+					{Input: "話だから", Want: "話ですので"},
+					{Input: "それは大事な話だから、よく聞いて。", Want: "そちらは大事な話ですので、よく聞いて。"},
+				},
 			},
-			EnableKutenToExclamation: true,
-		},
 
-		{
-			Value: "ですので",
-			Conditions: ConvertConditions{
-				newCond([]string{"助動詞"}, "だ"),
-				newCond([]string{"助詞", "接続助詞"}, "から"),
+			{
+				Value: "なんですの",
+				Conditions: ConvertConditions{
+					newCond([]string{"助動詞"}, "な"),
+					newCond([]string{"名詞", "非自立", "一般"}, "ん"),
+					newCond([]string{"助動詞"}, "だ"),
+				},
+				ID:          "な+ん+だ",
+				Description: "「なんだ」を「なんですの」に変換する",
+				Examples: []Example{
+					{Input: "ハーブが好きなんだ", Want: "おハーブが好きなんですの"},
+					{Input: "実はハーブを育てるのが好きなんだ。", Want: "実はおハーブを育てるのが好きなんですの。"},
+				},
 			},
-			EnableKutenToExclamation: true,
-		},
 
-		{
-			Value: "なんですの",
-			Conditions: ConvertConditions{
-				newCond([]string{"助動詞"}, "な"),
-				newCond([]string{"名詞", "非自立", "一般"}, "ん"),
-				newCond([]string{"助動詞"}, "だ"),
+			{
+				Value: "ですわ",
+				Conditions: ConvertConditions{
+					newCond([]string{"助動詞"}, "だ"),
+					newCond([]string{"助詞", "終助詞"}, "よ"),
+				},
+				ID:          "だ+よ",
+				Description: "「だよ」を「ですわ」に変換する",
+				Examples: []Example{
+					{Input: "話だよ", Want: "話ですわ"},
+					{Input: "それは大事な話だよ。", Want: "そちらは大事な話ですわ。"},
+				},
 			},
-			EnableKutenToExclamation: true,
-		},
 
-		{
-			Value: "ですわ",
-			Conditions: ConvertConditions{
-				newCond([]string{"助動詞"}, "だ"),
-				newCond([]string{"助詞", "終助詞"}, "よ"),
+			// 終助詞の「さ」だけを取り除くと文末が「た」になり、変換し直すと「たわ」になってしまうため、まとめて変換する
+			{
+				Value:          "たわ",
+				AppendLongNote: true,
+				Conditions: ConvertConditions{
+					newCond(pos.AuxiliaryVerb, "た"),
+					newCond(pos.SentenceEndingParticle, "さ"),
+				},
+				ID:          "た+さ",
+				Description: "「たさ」を「たわ」に変換する",
+				Examples: []Example{
+					{Input: "見てなかったさ。", Want: "見てなかったわ。"},
+					{Input: "昨日はずっと家にいたさ。", Want: "昨日はずっとお家にいたわ。"},
+				},
 			},
-			EnableKutenToExclamation: true,
-		},
 
-		{
-			Value: "なんですの",
-			Conditions: ConvertConditions{
-				newCond(pos.PronounGeneral, "なん"),
-				newCond(pos.SubPostpositionalParticle, "じゃ"),
+			{
+				Value: "なんですの",
+				Conditions: ConvertConditions{
+					newCond(pos.PronounGeneral, "なん"),
+					newCond(pos.SubPostpositionalParticle, "じゃ"),
+				},
+				ID:          "なん+じゃ",
+				Description: "「なんじゃ」を「なんですの」に変換する",
+				Examples: []Example{
+					{Input: "なんじゃこれ", Want: "なんですのこちら"},
+					{Input: "なんじゃこのハーブは。", Want: "なんですのこちらのおハーブは。"},
+				},
 			},
-			EnableKutenToExclamation: true,
-		},
-		{
-			Value: "なんですの",
-			Conditions: ConvertConditions{
-				newCond(pos.PronounGeneral, "なん"),
-				newCond(pos.AuxiliaryVerb, "だ"),
+			{
+				Value: "なんですの",
+				Conditions: ConvertConditions{
+					newCond(pos.PronounGeneral, "なん"),
+					newCond(pos.AuxiliaryVerb, "だ"),
+				},
+				ID:          "なん+だ",
+				Description: "「なんだ」を「なんですの」に変換する",
+				Examples: []Example{
+					{Input: "なんだこれ", Want: "なんですのこちら"},
+					{Input: "なんだこの匂いは。", Want: "なんですのこちらのお匂いは。"},
+				},
 			},
-			EnableKutenToExclamation: true,
-		},
-		{
-			Value: "なんですの",
-			Conditions: ConvertConditions{
-				newCond(pos.PronounGeneral, "なん"),
-				newCond(pos.AssistantParallelParticle, "や"),
+			{
+				Value: "なんですの",
+				Conditions: ConvertConditions{
+					newCond(pos.PronounGeneral, "なん"),
+					newCond(pos.AssistantParallelParticle, "や"),
+				},
+				ID:          "なん+や",
+				Description: "「なんや」を「なんですの」に変換する",
+				Examples: []Example{
+					{Input: "なんやこれ", Want: "なんですのこちら"},
+					{Input: "なんやこのハーブは。", Want: "なんですのこちらのおハーブは。"},
+				},
 			},
-			EnableKutenToExclamation: true,
-		},
 
-		{
-			Value: "@1ですの",
-			Conditions: ConvertConditions{
-				condNounsGeneral,
-				newCond(pos.AuxiliaryVerb, "じゃ"),
+			{
+				Value: "@1ですの",
+				Conditions: ConvertConditions{
+					condNounsGeneral,
+					newCond(pos.AuxiliaryVerb, "じゃ"),
+				},
+				ID:          "名詞+じゃ",
+				Description: "名詞＋「じゃ」を名詞＋「ですの」に変換する",
+				Examples: []Example{
+					{Input: "バナナじゃ。", Want: "おバナナですの。"},
+					{Input: "これは私のハーブじゃ。", Want: "こちらは私のおハーブですの。"},
+				},
 			},
-			EnableKutenToExclamation: true,
-		},
-		{
-			Value: "@1ですの",
-			Conditions: ConvertConditions{
-				condNounsGeneral,
-				newCond(pos.AuxiliaryVerb, "だ"),
+			{
+				Value: "@1ですの",
+				Conditions: ConvertConditions{
+					condNounsGeneral,
+					newCond(pos.AuxiliaryVerb, "だ"),
+				},
+				ID:          "名詞+だ",
+				Description: "名詞＋「だ」を名詞＋「ですの」に変換する",
+				Examples: []Example{
+					{Input: "ハーブだ", Want: "おハーブですの"},
+					{Input: "このハーブは私の宝物だ。", Want: "こちらのおハーブは私のお宝物ですの。"},
+				},
 			},
-			EnableKutenToExclamation: true,
-		},
-		{
-			Value: "@1ですの",
-			Conditions: ConvertConditions{
-				condNounsGeneral,
-				newCond(pos.AuxiliaryVerb, "や"),
+			{
+				Value: "@1ですの",
+				Conditions: ConvertConditions{
+					condNounsGeneral,
+					newCond(pos.AuxiliaryVerb, "や"),
+				},
+				ID:          "名詞+や",
+				Description: "名詞＋「や」を名詞＋「ですの」に変換する",
+				Examples: []Example{
+					{Input: "ハーブや", Want: "おハーブですの"},
+					{Input: "このハーブは私の宝物や。", Want: "こちらのおハーブは私のお宝物ですの。"},
+				},
 			},
-			EnableKutenToExclamation: true,
-		},
 
-		{
-			Value: "@1ですの",
-			Conditions: ConvertConditions{
-				condPronounsGeneral,
-				newCond(pos.AuxiliaryVerb, "じゃ"),
+			{
+				Value: "@1ですの",
+				Conditions: ConvertConditions{
+					condPronounsGeneral,
+					newCond(pos.AuxiliaryVerb, "じゃ"),
+				},
+				ID:          "代名詞+じゃ",
+				Description: "代名詞＋「じゃ」を代名詞＋「ですの」に変換する",
+				Examples: []Example{
+					{Input: "あれじゃ。", Want: "あれですの。"},
+					{Input: "欲しいのはあれじゃ。", Want: "欲しいのはあれですの。"},
+				},
 			},
-			EnableKutenToExclamation: true,
-		},
-		{
-			Value: "@1ですの",
-			Conditions: ConvertConditions{
-				condPronounsGeneral,
-				newCond(pos.AuxiliaryVerb, "だ"),
+			{
+				Value: "@1ですの",
+				Conditions: ConvertConditions{
+					condPronounsGeneral,
+					newCond(pos.AuxiliaryVerb, "だ"),
+				},
+				ID:          "代名詞+だ",
+				Description: "代名詞＋「だ」を代名詞＋「ですの」に変換する",
+				Examples: []Example{
+					{Input: "それだ", Want: "それですの"},
+					{Input: "欲しいのはそれだ。", Want: "欲しいのはそれですの。"},
+				},
 			},
-			EnableKutenToExclamation: true,
-		},
-		{
-			Value: "@1ですの",
-			Conditions: ConvertConditions{
-				condPronounsGeneral,
-				newCond(pos.AuxiliaryVerb, "や"),
+			{
+				Value: "@1ですの",
+				Conditions: ConvertConditions{
+					condPronounsGeneral,
+					newCond(pos.AuxiliaryVerb, "や"),
+				},
+				ID:          "代名詞+や",
+				Description: "代名詞＋「や」を代名詞＋「ですの」に変換する",
+				Examples: []Example{
+					{Input: "それや", Want: "それですの"},
+					{Input: "欲しいのはそれや。", Want: "欲しいのはそれですの。"},
+				},
 			},
-			EnableKutenToExclamation: true,
-		},
 
-		// 名詞＋した＋終助詞は文の終わり
-		{
-			Value: "@1をいたしましたわ",
-			Conditions: ConvertConditions{
-				condNounsGeneral,
-				newCond(pos.VerbIndependence, "し"),
-				newCond(pos.AuxiliaryVerb, "た"),
-				ConvertCondition{Features: pos.SentenceEndingParticle},
+			// 名詞＋した＋終助詞は文の終わり
+			{
+				Value: "@1をいたしましたわ",
+				Conditions: ConvertConditions{
+					condNounsGeneral,
+					newCond(pos.VerbIndependence, "し"),
+					newCond(pos.AuxiliaryVerb, "た"),
+					ConvertCondition{Features: pos.SentenceEndingParticle},
+				},
+				ID:          "名詞+し+た+終助詞",
+				Description: "名詞＋「した」＋終助詞を名詞＋「をいたしましたわ」に変換する",
+				Examples: []Example{
+					{Input: "ハーブしたよ", Want: "おハーブをいたしましたわ"},
+					{Input: "昨日は一日中ゲームしたよ。", Want: "昨日は一日中おゲームをいたしましたわ。"},
+				},
 			},
-			EnableKutenToExclamation: true,
-		},
 
-		// 名詞＋やる＋終助詞は文の終わり
-		{
-			Value: "@1をいたしましたわ",
-			Conditions: ConvertConditions{
-				condNounsGeneral,
-				newCond(pos.VerbIndependence, "やっ"),
-				newCond(pos.AuxiliaryVerb, "た"),
-				ConvertCondition{Features: pos.SentenceEndingParticle},
+			// 名詞＋やる＋終助詞は文の終わり
+			{
+				Value: "@1をいたしましたわ",
+				Conditions: ConvertConditions{
+					condNounsGeneral,
+					newCond(pos.VerbIndependence, "やっ"),
+					newCond(pos.AuxiliaryVerb, "た"),
+					ConvertCondition{Features: pos.SentenceEndingParticle},
+				},
+				ID:          "名詞+やっ+た+終助詞",
+				Description: "名詞＋「やった」＋終助詞を名詞＋「をいたしましたわ」に変換する",
+				Examples: []Example{
+					{Input: "ハーブやったよ", Want: "おハーブをいたしましたわ"},
+					{Input: "昨日は一日中ゲームやったよ。", Want: "昨日は一日中おゲームをいたしましたわ。"},
+				},
 			},
-			EnableKutenToExclamation: true,
-		},
+		}),
+	)
+
+	// OjosamaStyleRules はすでにお嬢様言葉になっている言葉の、連続する条件。
+	//
+	// 変換済みの文章をさらに変換すると「ですわですわ」のようになってしまうため、
+	// これらの条件にマッチした場合は変換せずにそのまま出力する。
+	OjosamaStyleRules = []ConvertConditions{
+		// ですわ、ますわ、たわ、ませんわ
+		{newCond(pos.AuxiliaryVerb, "です"), newCondSentenceEndingParticle("わ")},
+		{newCond(pos.AuxiliaryVerb, "ます"), newCondSentenceEndingParticle("わ")},
+		{newCond(pos.AuxiliaryVerb, "た"), newCondSentenceEndingParticle("わ")},
+		{newCond(pos.AuxiliaryVerb, "ん"), newCondSentenceEndingParticle("わ")},
+
+		// ますでしょう、ますので、ますん
+		// 「ます」の後ろが文の区切りでなくとも、変換結果としてすでにお嬢様言葉になっている
+		{newCond(pos.AuxiliaryVerb, "ます"), newCond(pos.AuxiliaryVerb, "でしょ")},
+		{newCond(pos.AuxiliaryVerb, "ます"), newCond(pos.ConnAssistant, "ので")},
+		{newCond(pos.AuxiliaryVerb, "ます"), newCond(pos.NotIndependenceGeneral, "ん")},
+
+		// ですわが
+		{newCond(pos.AuxiliaryVerb, "です"), {Surface: "わが"}},
+
+		// ですの、ですので、ですし
+		{condPronounsGeneral, newCond(pos.AuxiliaryVerb, "です"), {Surface: "の"}},
+		{newCond(pos.AuxiliaryVerb, "です"), {Surface: "の"}},
+		{newCond(pos.AuxiliaryVerb, "です"), newCond(pos.ConnAssistant, "ので")},
+		{newCond(pos.AuxiliaryVerb, "です"), newCond(pos.ConnAssistant, "し")},
+
+		// くださいまし、おりまし、くれますの、ありがとうございます
+		{newCond(pos.VerbNotIndependence, "ください"), newCond(pos.AuxiliaryVerb, "まし")},
+		{{Surface: "おり"}, newCond(pos.AuxiliaryVerb, "まし")},
+		{newCond(pos.VerbNotIndependence, "くれ"), newCond(pos.AuxiliaryVerb, "ます"), newCondSentenceEndingParticle("の")},
+		{newCond(pos.Interjection, "ありがとう"), newCond(pos.AuxiliaryVerb, "ござい")},
+
+		// こちらのような、そちらのような、あちらのような、どちらのような
+		{newCond(pos.PronounGeneral, "こちら"), {Surface: "の"}, {Surface: "よう"}},
+		{newCond(pos.PronounGeneral, "そちら"), {Surface: "の"}, {Surface: "よう"}},
+		{newCond(pos.PronounGeneral, "あちら"), {Surface: "の"}, {Surface: "よう"}},
+		{newCond(pos.PronounGeneral, "どちら"), {Surface: "の"}, {Surface: "よう"}},
+
+		// 皆様方
+		{newCond(pos.NounsGeneral, "皆様"), {Surface: "方"}},
+
+		// くっせぇ
+		// 変換後の「くっせぇ」は「くっせ」と未知語の「ぇ」に分かれ、「お」を付与してしまうため、そのまま出力する
+		{newCond(pos.VerbIndependence, "くっせ"), {SurfaceRe: regexp.MustCompile(`^ぇ`)}},
 	}
 
 	// ExcludeRules は変換処理を無視するルール。
 	// このルールは ConvertRules よりも優先して評価される。
-	ExcludeRules = []ConvertRule{
+	ExcludeRules = categorize(CategoryExclusion, []ConvertRule{
 		{
 			Conditions: ConvertConditions{
 				newCond(pos.SpecificGeneral, "カス"),
 			},
+			ID:          "カス",
+			Description: "固有名詞の「カス」には「お」を付けない",
+			Examples:    []Example{{Input: "カスです", Want: "カスですわ"}},
 		},
 		{
 			Conditions: ConvertConditions{
 				newCondRe(pos.NounsGeneral, regexp.MustCompile(`^(ー+|～+)$`)),
 			},
+			ID:          "長音記号",
+			Description: "長音記号や波線だけの名詞には「お」を付けない",
+			Examples:    []Example{{Input: "ああーー", Want: "ああーー"}},
 		},
-	}
+	})
 
 	// ConvertRules は 単独のTokenに対して、Conditionsがすべてマッチしたときに変換するルール。
 	//
 	// 基本的な変換はここに定義する。
-	ConvertRules = []ConvertRule{
-		// 一人称
-		newRulePronounGeneral("俺", "私"),
-		newRulePronounGeneral("オレ", "ワタクシ"),
-		newRulePronounGeneral("おれ", "わたくし"),
-		newRulePronounGeneral("僕", "私"),
-		newRulePronounGeneral("ボク", "ワタクシ"),
-		newRulePronounGeneral("ぼく", "わたくし"),
-		newRulePronounGeneral("あたし", "わたくし"),
-		newRulePronounGeneral("わたし", "わたくし"),
-
-		// 二人称
-		newRulePronounGeneral("あなた", "貴方"),
-		newRulePronounGeneral("あんた", "貴方"),
-		newRulePronounGeneral("おまえ", "貴方"),
-		newRulePronounGeneral("お前", "貴方"),
-		newRulePronounGeneral("てめぇ", "貴方"),
-		newRulePronounGeneral("てめえ", "貴方"),
-		newRuleNounsGeneral("貴様", "貴方").disablePrefix(true),
-		// newRulePronounGeneral("きさま", "貴方"),
-		// newRulePronounGeneral("そなた", "貴方"),
-		newRulePronounGeneral("君", "貴方"),
-
-		// 三人称
-		// TODO: AfterIgnore系も簡単に定義できるようにしたい
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.NounsGeneral, "パパ"),
-			},
-			AfterIgnoreConditions: ConvertConditions{
-				{Surface: "上"},
-			},
-			Value: "パパ上",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.NounsGeneral, "ママ"),
+	ConvertRules = joinRules(
+		// 人称代名詞
+		categorize(CategoryPronoun, []ConvertRule{
+			// 一人称
+			newRulePronounGeneral("俺", "私"),
+			newRulePronounGeneral("オレ", "ワタクシ"),
+			newRulePronounGeneral("おれ", "わたくし"),
+			newRulePronounGeneral("僕", "私"),
+			newRulePronounGeneral("ボク", "ワタクシ"),
+			newRulePronounGeneral("ぼく", "わたくし"),
+			newRulePronounGeneral("あたし", "わたくし"),
+			newRulePronounGeneral("わたし", "わたくし"),
+
+			// 二人称
+			newRulePronounGeneral("あなた", "貴方"),
+			newRulePronounGeneral("あんた", "貴方"),
+			newRulePronounGeneral("おまえ", "貴方"),
+			newRulePronounGeneral("お前", "貴方"),
+			newRulePronounGeneral("てめぇ", "貴方"),
+			newRulePronounGeneral("てめえ", "貴方"),
+			newRuleNounsGeneral("貴様", "貴方").disablePrefix(true),
+			// newRulePronounGeneral("きさま", "貴方"),
+			// newRulePronounGeneral("そなた", "貴方"),
+			newRulePronounGeneral("君", "貴方"),
+
+			// 三人称
+			// TODO: AfterIgnore系も簡単に定義できるようにしたい
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.NounsGeneral, "パパ"),
+				},
+				AfterIgnoreConditions: ConvertConditions{
+					{Surface: "上"},
+				},
+				Value:       "パパ上",
+				ID:          "パパ",
+				Description: "「パパ」を「パパ上」に変換する",
+				Examples:    []Example{{Input: "パパが来た", Want: "おパパ上が来たわ"}},
 			},
-			AfterIgnoreConditions: ConvertConditions{
-				{Surface: "上"},
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.NounsGeneral, "ママ"),
+				},
+				AfterIgnoreConditions: ConvertConditions{
+					{Surface: "上"},
+				},
+				Value:       "ママ上",
+				ID:          "ママ",
+				Description: "「ママ」を「ママ上」に変換する",
+				Examples:    []Example{{Input: "ママが来た", Want: "おママ上が来たわ"}},
 			},
-			Value: "ママ上",
-		},
-		newRulePronounGeneral("皆", "皆様方"),
-		newRuleNounsGeneral("皆様", "皆様方").disablePrefix(true),
+			newRulePronounGeneral("皆", "皆様方"),
+			newRuleNounsGeneral("皆様", "皆様方").disablePrefix(true),
+		}),
 
 		// こそあど言葉
-		newRulePronounGeneral("これ", "こちら"),
-		newRulePronounGeneral("それ", "そちら"),
-		newRulePronounGeneral("あれ", "あちら"),
-		newRulePronounGeneral("どれ", "どちら"),
-		newRuleAdnominalAdjective("この", "こちらの"),
-		newRuleAdnominalAdjective("その", "そちらの"),
-		newRuleAdnominalAdjective("あの", "あちらの"),
-		newRuleAdnominalAdjective("どの", "どちらの"),
-		newRulePronounGeneral("ここ", "こちら"),
-		newRulePronounGeneral("そこ", "そちら"),
-		newRulePronounGeneral("あそこ", "あちら"),
-		newRulePronounGeneral("どこ", "どちら"),
-		newRuleAdnominalAdjective("こんな", "このような"),
-		newRuleAdnominalAdjective("そんな", "そのような"),
-		newRuleAdnominalAdjective("あんな", "あのような"),
-		newRuleAdnominalAdjective("どんな", "どのような"),
+		categorize(CategoryDemonstrative, []ConvertRule{
+			newRulePronounGeneral("これ", "こちら"),
+			newRulePronounGeneral("それ", "そちら"),
+			newRulePronounGeneral("あれ", "あちら").example("あれはハーブです", "あちらはおハーブですわ"),
+			newRulePronounGeneral("どれ", "どちら"),
+			newRuleAdnominalAdjective("この", "こちらの"),
+			newRuleAdnominalAdjective("その", "そちらの"),
+			newRuleAdnominalAdjective("あの", "あちらの").example("あのハーブです", "あちらのおハーブですわ"),
+			newRuleAdnominalAdjective("どの", "どちらの"),
+			// 「それだけ」は1単語の副詞として解析されるが、変換後は代名詞の「それ」に分かれるため、
+			// 代名詞の「それ」と同じように変換しておく
+			newRule(pos.AdverbParticleConn, "それだけ", "そちらだけ"),
+			newRulePronounGeneral("ここ", "こちら"),
+			newRulePronounGeneral("そこ", "そちら"),
+			newRulePronounGeneral("あそこ", "あちら"),
+			newRulePronounGeneral("どこ", "どちら"),
+			newRuleAdnominalAdjective("こんな", "こちらのような"),
+			newRuleAdnominalAdjective("そんな", "そちらのような"),
+			newRuleAdnominalAdjective("あんな", "あちらのような"),
+			newRuleAdnominalAdjective("どんな", "どちらのような"),
+		}),
 
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.NotIndependenceGeneral, "もん"),
-			},
-			Value: "もの",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.AuxiliaryVerb, "です"),
-			},
-			AfterIgnoreConditions: ConvertConditions{
-				{Features: pos.SubParEndParticle},
-			},
-			AppendLongNote:           true,
-			EnableKutenToExclamation: true,
-			Value:                    "ですわ",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.AuxiliaryVerb, "だ"),
-			},
-			AfterIgnoreConditions: ConvertConditions{
-				{Features: pos.SubParEndParticle},
+		// 文末表現
+		categorize(CategoryEnding, []ConvertRule{
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.AuxiliaryVerb, "です"),
+				},
+				AfterIgnoreConditions: ConvertConditions{
+					{Features: pos.SubParEndParticle},
+				},
+				AppendLongNote:           true,
+				EnableKutenToExclamation: true,
+				Value:                    "ですわ",
+				ID:                       "です",
+				Description:              "助動詞「です」を「ですわ」に変換する",
+				Examples: []Example{
+					{Input: "ハーブです", Want: "おハーブですわ"},
+					{Input: "このハーブはとても良い香りです。", Want: "こちらのおハーブはとても良いお香りですわ。"},
+				},
 			},
-			AppendLongNote:           true,
-			EnableKutenToExclamation: true,
-			Value:                    "ですわ",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.VerbIndependence, "する"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.AuxiliaryVerb, "だ"),
+				},
+				AfterIgnoreConditions: ConvertConditions{
+					{Features: pos.SubParEndParticle},
+				},
+				AppendLongNote:           true,
+				EnableKutenToExclamation: true,
+				Value:                    "ですわ",
+				ID:                       "だ",
+				Description:              "助動詞「だ」を「ですわ」に変換する",
+				Examples: []Example{
+					{Input: "綺麗だ", Want: "綺麗ですわ"},
+					{Input: "このハーブは本当に綺麗だ。", Want: "こちらのおハーブは本当に綺麗ですわ。"},
+				},
 			},
-			EnableWhenSentenceSeparation: true,
-			AppendLongNote:               true,
-			EnableKutenToExclamation:     true,
-			Value:                        "いたしますわ",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.VerbIndependence, "なる"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.VerbIndependence, "する"),
+				},
+				EnableWhenSentenceSeparation: true,
+				AppendLongNote:               true,
+				EnableKutenToExclamation:     true,
+				Value:                        "いたしますわ",
+				ID:                           "する",
+				Description:                  "文末の「する」を「いたしますわ」に変換する",
+				Examples: []Example{
+					{Input: "ハーブを栽培する", Want: "おハーブを栽培いたしますわ"},
+					{Input: "毎朝ハーブに水やりをする。", Want: "毎朝おハーブに水やりをいたしますわ。"},
+				},
 			},
-			EnableWhenSentenceSeparation: true,
-			AppendLongNote:               true,
-			EnableKutenToExclamation:     true,
-			Value:                        "なりますわ",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.VerbIndependence, "ある"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.VerbIndependence, "なる"),
+				},
+				EnableWhenSentenceSeparation: true,
+				AppendLongNote:               true,
+				EnableKutenToExclamation:     true,
+				Value:                        "なりますわ",
+				ID:                           "なる",
+				Description:                  "文末の「なる」を「なりますわ」に変換する",
+				Examples: []Example{
+					{Input: "大人になる", Want: "大人になりますわ"},
+					{Input: "このハーブはすぐに大きくなる。", Want: "こちらのおハーブはすぐに大きくなりますわ。"},
+				},
 			},
-			Value: "あります",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.SubPostpositionalParticle, "じゃ"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.SubParEndParticle, "か"),
+				},
+				Value:       "の",
+				ID:          "か",
+				Description: "疑問の「か」を「の」に変換する",
+				Examples: []Example{
+					{Input: "これは何か", Want: "こちらは何の"},
+					{Input: "このハーブの名前は何か。", Want: "こちらのおハーブのお名前は何の。"},
+				},
 			},
-			Value: "では",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.SubParEndParticle, "か"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.SentenceEndingParticle, "わ"),
+				},
+				AppendLongNote:           true,
+				EnableKutenToExclamation: true,
+				Value:                    "ですわ",
+				ID:                       "わ",
+				Description:              "終助詞「わ」を「ですわ」に変換する",
+				Examples: []Example{
+					{Input: "いいわ", Want: "いいですわ"},
+					{Input: "それでいいわ。", Want: "そちらでいいですわ。"},
+				},
 			},
-			Value: "の",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.SentenceEndingParticle, "わ"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.SentenceEndingParticle, "な"),
+				},
+				Value:       "ね",
+				ID:          "な",
+				Description: "終助詞「な」を「ね」に変換する",
+				Examples: []Example{
+					{Input: "そうだな", Want: "そうですわね"},
+					{Input: "今日は暑いな。", Want: "今日は暑いね。"},
+				},
 			},
-			AppendLongNote:           true,
-			EnableKutenToExclamation: true,
-			Value:                    "ですわ",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.SentenceEndingParticle, "な"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.SentenceEndingParticle, "さ"),
+				},
+				Value:       "",
+				ID:          "さ",
+				Description: "終助詞「さ」を取り除く",
+				Examples: []Example{
+					{Input: "そうさ", Want: "そう"},
+					{Input: "まあ、そういうもんさ。", Want: "まあ、そういうもの。"},
+				},
 			},
-			Value: "ね",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.SentenceEndingParticle, "さ"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.AuxiliaryVerb, "ます"),
+				},
+				AppendLongNote:           true,
+				EnableKutenToExclamation: true,
+				Value:                    "ますわ",
+				ID:                       "ます",
+				Description:              "文末の「ます」を「ますわ」に変換する",
+				Examples: []Example{
+					{Input: "ハーブを育てます", Want: "おハーブを育てますわ"},
+					{Input: "明日もハーブを育てます。", Want: "明日もおハーブを育てますわ。"},
+				},
 			},
-			Value: "",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.ConnAssistant, "から"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.AuxiliaryVerb, "た"),
+				},
+				EnableWhenSentenceSeparation: true,
+				AppendLongNote:               true,
+				EnableKutenToExclamation:     true,
+				Value:                        "たわ",
+				ID:                           "た",
+				Description:                  "文末の「た」を「たわ」に変換する",
+				Examples: []Example{
+					{Input: "ハーブを育てた", Want: "おハーブを育てたわ"},
+					{Input: "昨日ハーブの種を植えた。", Want: "昨日おハーブのお種を植えたわ。"},
+				},
 			},
-			Value: "ので",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.ConnAssistant, "けど"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.AuxiliaryVerb, "だろ"),
+				},
+				Value:       "でしょう",
+				ID:          "だろ",
+				Description: "「だろ」を「でしょう」に変換する",
+				Examples: []Example{
+					{Input: "ハーブだろ", Want: "おハーブでしょう"},
+					{Input: "このハーブは高いだろ。", Want: "こちらのおハーブは高いでしょう。"},
+				},
 			},
-			Value: "けれど",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.ConnAssistant, "し"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.VerbNotIndependence, "ください"),
+				},
+				EnableKutenToExclamation: true,
+				Value:                    "くださいまし",
+				ID:                       "ください",
+				Description:              "「ください」を「くださいまし」に変換する",
+				Examples: []Example{
+					{Input: "見てください", Want: "見てくださいまし"},
+					{Input: "このハーブを見てください。", Want: "こちらのおハーブを見てくださいまし。"},
+				},
 			},
-			Value: "ですし",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.AuxiliaryVerb, "まし"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.VerbNotIndependence, "くれ"),
+				},
+				EnableKutenToExclamation: true,
+				Value:                    "くださいまし",
+				ID:                       "くれ",
+				Description:              "「くれ」を「くださいまし」に変換する",
+				Examples: []Example{
+					{Input: "教えてくれ", Want: "教えてくださいまし"},
+					{Input: "ハーブの育て方を教えてくれ。", Want: "おハーブの育て方を教えてくださいまし。"},
+				},
 			},
-			BeforeIgnoreConditions: ConvertConditions{
-				{Features: pos.VerbIndependence},
+		}),
+
+		// その他
+		categorize(CategoryOther, []ConvertRule{
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.NotIndependenceGeneral, "もん"),
+				},
+				Value:       "もの",
+				ID:          "もん",
+				Description: "「もん」を「もの」に変換する",
+				Examples:    []Example{{Input: "大事なもんです", Want: "大事なものですわ"}},
 			},
-			Value: "おりまし",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.AuxiliaryVerb, "ます"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.VerbIndependence, "ある"),
+				},
+				Value:       "あります",
+				ID:          "ある",
+				Description: "「ある」を「あります」に変換する",
+				Examples:    []Example{{Input: "ハーブがある", Want: "おハーブがあります"}},
 			},
-			AppendLongNote:           true,
-			EnableKutenToExclamation: true,
-			Value:                    "ますわ",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.AuxiliaryVerb, "た"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.SubPostpositionalParticle, "じゃ"),
+				},
+				Value:       "では",
+				ID:          "じゃ",
+				Description: "副助詞「じゃ」を「では」に変換する",
+				Examples:    []Example{{Input: "それじゃ", Want: "そちらでは"}},
 			},
-			EnableWhenSentenceSeparation: true,
-			AppendLongNote:               true,
-			EnableKutenToExclamation:     true,
-			Value:                        "たわ",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.AuxiliaryVerb, "だろ"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.ConnAssistant, "から"),
+				},
+				Value:       "ので",
+				ID:          "から",
+				Description: "接続助詞「から」を「ので」に変換する",
+				Examples:    []Example{{Input: "行くから", Want: "行くので"}},
 			},
-			Value: "でしょう",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.AuxiliaryVerb, "ない"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.ConnAssistant, "けど"),
+				},
+				Value:       "けれど",
+				ID:          "けど",
+				Description: "「けど」を「けれど」に変換する",
+				Examples:    []Example{{Input: "高いけど", Want: "高いけれど"}},
 			},
-			BeforeIgnoreConditions: ConvertConditions{
-				{Features: pos.VerbIndependence},
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.ConnAssistant, "し"),
+				},
+				Value:       "ですし",
+				ID:          "し",
+				Description: "接続助詞「し」を「ですし」に変換する",
+				Examples:    []Example{{Input: "安いし", Want: "安いですし"}},
 			},
-			Value: "ありません",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.VerbNotIndependence, "ください"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.AuxiliaryVerb, "まし"),
+				},
+				BeforeIgnoreConditions: ConvertConditions{
+					{Features: pos.VerbIndependence},
+				},
+				Value:       "おりまし",
+				ID:          "まし",
+				Description: "自立動詞に続かない「まし」を「おりまし」に変換する",
+				Examples:    []Example{{Input: "寝てました", Want: "寝ておりましたわ"}},
 			},
-			EnableKutenToExclamation: true,
-			Value:                    "くださいまし",
-		},
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.VerbNotIndependence, "くれ"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.AuxiliaryVerb, "ない"),
+				},
+				BeforeIgnoreConditions: ConvertConditions{
+					{Features: pos.VerbIndependence},
+				},
+				Value:       "ありません",
+				ID:          "ない",
+				Description: "自立動詞に続かない「ない」を「ありません」に変換する",
+				Examples:    []Example{{Input: "雨じゃない", Want: "お雨ではありません"}},
 			},
-			EnableKutenToExclamation: true,
-			Value:                    "くださいまし",
-		},
-		newRuleInterjection("ありがとう", "ありがとうございますわ"),
-		newRuleInterjection("じゃぁ", "それでは"),
-		newRuleInterjection("じゃあ", "それでは"),
-		{
-			Conditions: ConvertConditions{
-				newCond(pos.VerbNotIndependence, "くれる"),
+			{
+				Conditions: ConvertConditions{
+					newCond(pos.VerbNotIndependence, "くれる"),
+				},
+				Value:       "くれます",
+				ID:          "くれる",
+				Description: "「くれる」を「くれます」に変換する",
+				Examples:    []Example{{Input: "手伝ってくれる", Want: "手伝ってくれます"}},
 			},
-			Value: "くれます",
-		},
-		newRuleAdjectivesSelfSupporting("汚い", "きったねぇ"),
-		newRuleAdjectivesSelfSupporting("きたない", "きったねぇ"),
-		newRuleAdjectivesSelfSupporting("臭い", "くっせぇ"),
-		newRuleAdjectivesSelfSupporting("くさい", "くっせぇ"),
-		newRuleInterjection("うふ", "おほ"),
-		newRuleInterjection("うふふ", "おほほ"),
-		newRuleInterjection("う", "お"),
-		newRuleInterjection("ふふふ", "ほほほ"),
+		}),
+
+		// 感動詞
+		categorize(CategoryInterjection, []ConvertRule{
+			newRuleInterjection("ありがとう", "ありがとうございますわ"),
+			newRuleInterjection("じゃぁ", "それでは"),
+			newRuleInterjection("じゃあ", "それでは"),
+			newRuleInterjection("うふ", "おほ"),
+			newRuleInterjection("うふふ", "おほほ"),
+			newRuleInterjection("う", "お"),
+			newRuleInterjection("ふふふ", "ほほほ"),
+		}),
+
+		// 下品な言葉
+		categorize(CategoryVulgar, []ConvertRule{
+			newRuleAdjectivesSelfSupporting("汚い", "きったねぇ"),
+			newRuleAdjectivesSelfSupporting("きたない", "きったねぇ"),
+			newRuleAdjectivesSelfSupporting("臭い", "くっせぇ"),
+			newRuleAdjectivesSelfSupporting("くさい", "くっせぇ"),
+		}),
 
 		// 形容詞文。形容詞で文が終わる時に変換する
-		{
-			Conditions: ConvertConditions{
-				{Features: pos.AdjectivesSelfSupporting},
+		// すべての形容詞にマッチするため、下品な言葉の変換ルールよりも後に評価する
+		categorize(CategoryAdjective, []ConvertRule{
+			{
+				Conditions: ConvertConditions{
+					{Features: pos.AdjectivesSelfSupporting},
+				},
+				EnableWhenSentenceSeparation: true,
+				EnableKutenToExclamation:     true,
+				AppendLongNote:               true,
+				Value:                        "@1ですわ",
+				ID:                           "文末",
+				Description:                  "形容詞で終わる文の末尾に「ですわ」を付ける",
+				Examples: []Example{
+					{Input: "ハーブは美しい", Want: "おハーブは美しいですわ"},
+					{Input: "庭のハーブはとても美しい。", Want: "お庭のおハーブはとても美しいですわ。"},
+				},
 			},
-			EnableWhenSentenceSeparation: true,
-			EnableKutenToExclamation:     true,
-			AppendLongNote:               true,
-			Value:                        "@1ですわ",
-		},
-	}
+		}),
+	)
 )
 
-func GetMeaningType(typeMap map[MeaningType]ConvertConditions, data tokenizer.TokenData) (MeaningType, bool) {
-	for k, cond := range typeMap {
-		if cond.MatchAnyTokenData(data) {
-			return k, true
+// SelectValue は意味分類 mt の変換候補から1つを選択する。
+//
+// 変換候補が複数ある場合は ValueWeights の重みに従ってランダムに選択する。
+// rnd が nil の場合は常に最初の変換候補を返す。
+// 変換候補が無い場合は false を返す。
+func (r SentenceEndingParticleConvertRule) SelectValue(mt MeaningType, rnd *rand.Rand) (string, bool) {
+	values := r.Value[mt]
+	if len(values) < 1 {
+		return "", false
+	}
+	if rnd == nil || len(values) == 1 {
+		return values[0], true
+	}
+
+	weights := r.ValueWeights[mt]
+	if len(weights) != len(values) {
+		return values[rnd.Intn(len(values))], true
+	}
+	var total int
+	for _, w := range weights {
+		total += w
+	}
+	if total < 1 {
+		return values[0], true
+	}
+	n := rnd.Intn(total)
+	for i, w := range weights {
+		n -= w
+		if n < 0 {
+			return values[i], true
 		}
 	}
-	return meaningTypeUnknown, false
+	// 到達しないはずだけれど一応いれてる
+	return values[0], true
+}
+
+// SentenceEndingParticleCondition は終助詞の1つの意味分類の条件。
+type SentenceEndingParticleCondition struct {
+	MeaningType MeaningType       // 意味分類
+	Priority    int               // 複数の意味分類の条件にマッチする時の優先度。大きいほど優先する
+	Conditions  ConvertConditions // いずれかにマッチすれば、この意味分類とみなす条件
+}
+
+// SentenceEndingParticleConditions は終助詞の意味分類ごとの条件。
+//
+// マップではなくスライスにすることで、評価する順序を固定している。
+type SentenceEndingParticleConditions []SentenceEndingParticleCondition
+
+// GetMeaningType は data がマッチする終助詞の意味分類を返す。
+//
+// 複数の意味分類にマッチする場合は優先度が最も大きいものを返し、
+// 優先度が同じ場合は先に定義したものを返す。
+// いずれにもマッチしない場合は false を返す。
+func GetMeaningType(conds SentenceEndingParticleConditions, data tokenizer.TokenData) (MeaningType, bool) {
+	result := meaningTypeUnknown
+	var priority int
+	found := false
+	for _, c := range conds {
+		if found && c.Priority <= priority {
+			continue
+		}
+		if c.Conditions.MatchAnyTokenData(data) {
+			result = c.MeaningType
+			priority = c.Priority
+			found = true
+		}
+	}
+	return result, found
+}
+
+// FindAmbiguousMeaningTypes は同じTokenにマッチしうる条件を持ち、
+// 優先度が同じ意味分類の組を返す。
+//
+// 該当する組が無い場合は false を返す。
+// 正規表現同士の条件は同じTokenにマッチしうるものとみなす。
+func (c SentenceEndingParticleConditions) FindAmbiguousMeaningTypes() (MeaningType, MeaningType, bool) {
+	for i, a := range c {
+		for _, b := range c[i+1:] {
+			if a.MeaningType == b.MeaningType || a.Priority != b.Priority {
+				continue
+			}
+			if a.Conditions.mayOverlap(b.Conditions) {
+				return a.MeaningType, b.MeaningType, true
+			}
+		}
+	}
+	return meaningTypeUnknown, meaningTypeUnknown, false
 }
@@ -1,37 +1,46 @@
 package converter
 
 import (
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/ikawaha/kagome/v2/tokenizer"
+	"github.com/jiro4989/ojosama/internal/pos"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestGetMeaningType(t *testing.T) {
 	tests := []struct {
-		desc    string
-		typeMap map[MeaningType]ConvertConditions
-		data    tokenizer.TokenData
-		wantMT  MeaningType
-		wantOK  bool
+		desc   string
+		conds  SentenceEndingParticleConditions
+		data   tokenizer.TokenData
+		wantMT MeaningType
+		wantOK bool
 	}{
 		{
 			desc: "正常系: 一致したmeaningTypeを返却いたしますわ",
-			typeMap: map[MeaningType]ConvertConditions{
-				meaningTypeCoercion: {
-					{
-						Features: []string{"名詞"},
-						Reading:  "a",
-					},
-					{
-						Features: []string{"名詞"},
-						Reading:  "b",
+			conds: SentenceEndingParticleConditions{
+				{
+					MeaningType: meaningTypeCoercion,
+					Conditions: ConvertConditions{
+						{
+							Features: []string{"名詞"},
+							Reading:  "a",
+						},
+						{
+							Features: []string{"名詞"},
+							Reading:  "b",
+						},
 					},
 				},
-				meaningTypeHope: {
-					{
-						Features: []string{"名詞"},
-						Reading:  "c",
+				{
+					MeaningType: meaningTypeHope,
+					Conditions: ConvertConditions{
+						{
+							Features: []string{"名詞"},
+							Reading:  "c",
+						},
 					},
 				},
 			},
@@ -42,17 +51,53 @@ func TestGetMeaningType(t *testing.T) {
 			wantMT: meaningTypeCoercion,
 			wantOK: true,
 		},
+		{
+			desc: "正常系: 複数一致した場合は優先度の大きいmeaningTypeを返却いたしますわ",
+			conds: SentenceEndingParticleConditions{
+				{
+					MeaningType: meaningTypeHope,
+					Conditions:  ConvertConditions{{Surface: "よ"}},
+				},
+				{
+					MeaningType: meaningTypeCoercion,
+					Priority:    1,
+					Conditions:  ConvertConditions{{Surface: "よ"}},
+				},
+			},
+			data:   tokenizer.TokenData{Surface: "よ"},
+			wantMT: meaningTypeCoercion,
+			wantOK: true,
+		},
+		{
+			desc: "正常系: 優先度が同じ場合は先に定義したmeaningTypeを返却いたしますわ",
+			conds: SentenceEndingParticleConditions{
+				{
+					MeaningType: meaningTypeProhibition,
+					Conditions:  ConvertConditions{{Surface: "よ"}},
+				},
+				{
+					MeaningType: meaningTypeHope,
+					Conditions:  ConvertConditions{{Surface: "よ"}},
+				},
+			},
+			data:   tokenizer.TokenData{Surface: "よ"},
+			wantMT: meaningTypeProhibition,
+			wantOK: true,
+		},
 		{
 			desc: "正常系: いずれとも一致しない場合はunknownですわ",
-			typeMap: map[MeaningType]ConvertConditions{
-				meaningTypeCoercion: {
-					{
-						Features: []string{"名詞"},
-						Reading:  "z",
-					},
-					{
-						Features: []string{"名詞"},
-						Surface:  "z",
+			conds: SentenceEndingParticleConditions{
+				{
+					MeaningType: meaningTypeCoercion,
+					Conditions: ConvertConditions{
+						{
+							Features: []string{"名詞"},
+							Reading:  "z",
+						},
+						{
+							Features: []string{"名詞"},
+							Surface:  "z",
+						},
 					},
 				},
 			},
@@ -69,9 +114,208 @@ func TestGetMeaningType(t *testing.T) {
 		t.Run(tt.desc, func(t *testing.T) {
 			assert := assert.New(t)
 
-			gotMT, gotOK := GetMeaningType(tt.typeMap, tt.data)
+			// 何度評価しても同じ結果になりますわ
+			for i := 0; i < 10; i++ {
+				gotMT, gotOK := GetMeaningType(tt.conds, tt.data)
+				assert.Equal(tt.wantMT, gotMT)
+				assert.Equal(tt.wantOK, gotOK)
+			}
+		})
+	}
+}
+
+func TestFindAmbiguousMeaningTypes(t *testing.T) {
+	tests := []struct {
+		desc   string
+		conds  SentenceEndingParticleConditions
+		wantOK bool
+	}{
+		{
+			desc: "正常系: 表層形が違う場合は曖昧ではありませんわ",
+			conds: SentenceEndingParticleConditions{
+				{MeaningType: meaningTypeHope, Conditions: ConvertConditions{newCondSentenceEndingParticle("よ")}},
+				{MeaningType: meaningTypeCoercion, Conditions: ConvertConditions{newCondSentenceEndingParticle("ぞ")}},
+			},
+			wantOK: false,
+		},
+		{
+			desc: "正常系: 品詞が違う場合は曖昧ではありませんわ",
+			conds: SentenceEndingParticleConditions{
+				{MeaningType: meaningTypeHope, Conditions: ConvertConditions{newCondSentenceEndingParticle("か")}},
+				{MeaningType: meaningTypePoem, Conditions: ConvertConditions{newCond([]string{"助詞", "副助詞／並立助詞／終助詞"}, "か")}},
+			},
+			wantOK: false,
+		},
+		{
+			desc: "正常系: 優先度が違う場合は曖昧ではありませんわ",
+			conds: SentenceEndingParticleConditions{
+				{MeaningType: meaningTypeHope, Conditions: ConvertConditions{newCondSentenceEndingParticle("よ")}},
+				{MeaningType: meaningTypeCoercion, Priority: 1, Conditions: ConvertConditions{newCondSentenceEndingParticle("よ")}},
+			},
+			wantOK: false,
+		},
+		{
+			desc: "異常系: 同じ終助詞に優先度が同じ意味分類がある場合は曖昧ですわ",
+			conds: SentenceEndingParticleConditions{
+				{MeaningType: meaningTypeHope, Conditions: ConvertConditions{newCondSentenceEndingParticle("よ")}},
+				{MeaningType: meaningTypeCoercion, Conditions: ConvertConditions{newCondSentenceEndingParticle("よ")}},
+			},
+			wantOK: true,
+		},
+		{
+			desc: "異常系: 正規表現がもう一方の表層形にマッチする場合は曖昧ですわ",
+			conds: SentenceEndingParticleConditions{
+				{MeaningType: meaningTypeHope, Conditions: ConvertConditions{newCondSentenceEndingParticle("よ")}},
+				{MeaningType: meaningTypeCoercion, Conditions: ConvertConditions{newCondRe(nil, regexp.MustCompile(`^(よ|ぞ)$`))}},
+			},
+			wantOK: true,
+		},
+		{
+			desc: "異常系: 表層形の条件が無い場合はどの終助詞にもマッチするため曖昧ですわ",
+			conds: SentenceEndingParticleConditions{
+				{MeaningType: meaningTypeHope, Conditions: ConvertConditions{newCondSentenceEndingParticle("よ")}},
+				{MeaningType: meaningTypeCoercion, Conditions: ConvertConditions{{Features: pos.SentenceEndingParticle}}},
+			},
+			wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			_, _, gotOK := tt.conds.FindAmbiguousMeaningTypes()
+			assert.Equal(tt.wantOK, gotOK)
+		})
+	}
+}
+
+// 組み込みの変換ルールで同じ終助詞が複数の意味分類にマッチしうる場合は、
+// 優先度を明示しなければ変換結果が定まらないため失敗させる。
+func TestSentenceEndingParticleConvertRulesAreUnambiguous(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, r := range SentenceEndingParticleConvertRules {
+		a, b, ok := r.SentenceEndingParticle.FindAmbiguousMeaningTypes()
+		assert.False(ok, "%s: %s and %s need different priorities", r.ID, a, b)
+	}
+}
+
+func TestParseMeaningType(t *testing.T) {
+	tests := []struct {
+		desc   string
+		s      string
+		wantMT MeaningType
+		wantOK bool
+	}{
+		{
+			desc:   "正常系: 名前から意味分類を取得できますわ",
+			s:      "hope",
+			wantMT: meaningTypeHope,
+			wantOK: true,
+		},
+		{
+			desc:   "正常系: String の結果から元の意味分類を取得できますわ",
+			s:      meaningTypeCoercion.String(),
+			wantMT: meaningTypeCoercion,
+			wantOK: true,
+		},
+		{
+			desc:   "異常系: unknown は意味分類として扱いませんわ",
+			s:      "unknown",
+			wantMT: meaningTypeUnknown,
+			wantOK: false,
+		},
+		{
+			desc:   "異常系: 不明な名前ですわ",
+			s:      "hoge",
+			wantMT: meaningTypeUnknown,
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			gotMT, gotOK := ParseMeaningType(tt.s)
 			assert.Equal(tt.wantMT, gotMT)
 			assert.Equal(tt.wantOK, gotOK)
 		})
 	}
 }
+
+func TestRuleIDs(t *testing.T) {
+	assert := assert.New(t)
+
+	type meta struct {
+		id       string
+		desc     string
+		examples []Example
+	}
+	var rules []meta
+	for _, r := range SentenceEndingParticleConvertRules {
+		rules = append(rules, meta{r.ID, r.Description, r.Examples})
+	}
+	for _, r := range ContinuousConditionsConvertRules {
+		rules = append(rules, meta{r.ID, r.Description, r.Examples})
+	}
+	for _, r := range ExcludeRules {
+		rules = append(rules, meta{r.ID, r.Description, r.Examples})
+	}
+	for _, r := range ConvertRules {
+		rules = append(rules, meta{r.ID, r.Description, r.Examples})
+	}
+
+	// IDは一意で、説明と変換例をすべて持っていますわ
+	seen := map[string]bool{}
+	for _, r := range rules {
+		assert.NotEmpty(r.id)
+		assert.False(seen[r.id], r.id)
+		assert.NotEmpty(r.desc, r.id)
+		assert.NotEmpty(r.examples, r.id)
+		seen[r.id] = true
+	}
+}
+
+func TestCategoryNames(t *testing.T) {
+	assert := assert.New(t)
+
+	// すべての分類の名前を定義順に返し、名前から元の分類を取得できますわ
+	names := CategoryNames()
+	assert.Len(names, len(categoryNames))
+	assert.Equal("other", names[0])
+	assert.Equal("exclusion", names[len(names)-1])
+	for i, name := range names {
+		got, ok := ParseCategory(name)
+		assert.True(ok, name)
+		assert.Equal(Category(i), got)
+	}
+}
+
+func TestContextSensitiveRuleExamples(t *testing.T) {
+	assert := assert.New(t)
+
+	// 文章の変換例は句読点を含んでいますわ
+	hasSentence := func(examples []Example) bool {
+		for _, e := range examples {
+			if strings.ContainsAny(e.Input, "。、") {
+				return true
+			}
+		}
+		return false
+	}
+
+	// 前後の単語で変換が変わる変換ルールは、文章の変換例を持っていますわ
+	for _, r := range SentenceEndingParticleConvertRules {
+		assert.True(hasSentence(r.Examples), r.ID)
+	}
+	for _, r := range ContinuousConditionsConvertRules {
+		assert.True(hasSentence(r.Examples), r.ID)
+	}
+	for _, r := range ConvertRules {
+		if r.Category == CategoryEnding || r.Category == CategoryAdjective {
+			assert.True(hasSentence(r.Examples), r.ID)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package converter
+
+import (
+	"iter"
+
+	"github.com/ikawaha/kagome/v2/tokenizer"
+)
+
+// RuleIndex は変換ルールを Token の表層形、原形、先頭の品詞で引ける索引。
+//
+// 変換ルールが増えても、Token ごとにすべての変換ルールを判定しなくて済むように
+// マッチしうる変換ルールだけに絞り込む。
+// 表層形、原形、品詞のいずれも指定していない変換ルール（正規表現だけの条件など）は
+// どの Token でも候補にする。
+//
+// 絞り込むだけなので、候補の変換ルールの条件は呼び出し側で判定する必要がある。
+type RuleIndex struct {
+	bySurface  map[string][]int
+	byBaseForm map[string][]int
+	byPOS      map[string][]int
+	fallback   []int
+}
+
+// NewRuleIndex は変換ルールの条件の索引を生成する。
+//
+// conds の i 番目の要素は i 番目の変換ルールの条件で、すべての条件を AND で評価する。
+func NewRuleIndex(conds []ConvertConditions) *RuleIndex {
+	x := &RuleIndex{
+		bySurface:  make(map[string][]int),
+		byBaseForm: make(map[string][]int),
+		byPOS:      make(map[string][]int),
+	}
+	for i, c := range conds {
+		x.add(i, c)
+	}
+	return x
+}
+
+// NewConvertRuleIndex は ConvertRule の Conditions の索引を生成する。
+func NewConvertRuleIndex(rules []ConvertRule) *RuleIndex {
+	conds := make([]ConvertConditions, len(rules))
+	for i, r := range rules {
+		conds[i] = r.Conditions
+	}
+	return NewRuleIndex(conds)
+}
+
+// NewContinuousRuleIndex は ContinuousConditionsConvertRule の先頭の条件の索引を生成する。
+//
+// 連続する条件の起点になる Token で候補を絞り込むために使う。
+func NewContinuousRuleIndex(rules []ContinuousConditionsConvertRule) *RuleIndex {
+	conds := make([]ConvertConditions, len(rules))
+	for i, r := range rules {
+		if 0 < len(r.Conditions) {
+			conds[i] = r.Conditions[:1]
+		}
+	}
+	return NewRuleIndex(conds)
+}
+
+// add は i 番目の変換ルールを、最も絞り込める条件で索引に追加する。
+//
+// すべての条件が一致する必要があるため、いずれか1つの条件で引ければ良い。
+// 表層形、原形、品詞の順に優先する。
+func (x *RuleIndex) add(i int, conds ConvertConditions) {
+	for _, c := range conds {
+		if c.Surface != "" {
+			x.bySurface[c.Surface] = append(x.bySurface[c.Surface], i)
+			return
+		}
+	}
+	for _, c := range conds {
+		if c.BaseForm != "" {
+			x.byBaseForm[c.BaseForm] = append(x.byBaseForm[c.BaseForm], i)
+			return
+		}
+	}
+	for _, c := range conds {
+		if 0 < len(c.Features) && c.Features[0] != "*" {
+			x.byPOS[c.Features[0]] = append(x.byPOS[c.Features[0]], i)
+			return
+		}
+	}
+	x.fallback = append(x.fallback, i)
+}
+
+// Candidates は data にマッチしうる変換ルールの位置を昇順に返す。
+//
+// 変換ルールは定義順に評価して最初にマッチしたものを使うため、
+// 昇順に返すことで索引を使わない場合と同じ優先順位になる。
+func (x *RuleIndex) Candidates(data tokenizer.TokenData) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		lists := [4][]int{
+			x.bySurface[data.Surface],
+			x.byBaseForm[data.BaseForm],
+			nil,
+			x.fallback,
+		}
+		if 0 < len(data.Features) {
+			lists[2] = x.byPOS[data.Features[0]]
+		}
+
+		// 変換ルールはいずれか1つのリストにしか含まれないため、
+		// 各リストの先頭で最も小さい位置を順に取り出せば昇順になる
+		for {
+			min := -1
+			for k, l := range lists {
+				if 0 < len(l) && (min < 0 || l[0] < lists[min][0]) {
+					min = k
+				}
+			}
+			if min < 0 {
+				return
+			}
+			i := lists[min][0]
+			lists[min] = lists[min][1:]
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
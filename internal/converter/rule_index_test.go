@@ -0,0 +1,80 @@
+package converter
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/ikawaha/kagome/v2/tokenizer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleIndexCandidates(t *testing.T) {
+	conds := []ConvertConditions{
+		{{Features: []string{"名詞", "代名詞", "一般"}, Surface: "俺"}},
+		{{Features: []string{"名詞", "一般"}}},
+		{{BaseForm: "する"}},
+		{{SurfaceRe: regexp.MustCompile(`^ハ`)}},
+		{{Surface: "俺"}},
+		{{Features: []string{"動詞", "自立"}}, {Surface: "し"}},
+		nil,
+	}
+	x := NewRuleIndex(conds)
+
+	tests := []struct {
+		desc string
+		data tokenizer.TokenData
+		want []int
+	}{
+		{
+			desc: "正常系: 表層形と品詞で引いた変換ルールを定義順に返しますわ",
+			data: tokenizer.TokenData{Surface: "俺", BaseForm: "俺", Features: []string{"名詞", "代名詞", "一般", "*"}},
+			want: []int{0, 1, 3, 4, 6},
+		},
+		{
+			desc: "正常系: 原形で引けますわ",
+			data: tokenizer.TokenData{Surface: "し", BaseForm: "する", Features: []string{"動詞", "自立", "*"}},
+			want: []int{2, 3, 5, 6},
+		},
+		{
+			desc: "正常系: どれにも一致しない場合は条件を絞り込めない変換ルールだけですわ",
+			data: tokenizer.TokenData{Surface: "ハーブ", BaseForm: "ハーブ", Features: []string{"記号"}},
+			want: []int{3, 6},
+		},
+		{
+			desc: "正常系: 品詞が無い Token でも引けますわ",
+			data: tokenizer.TokenData{Surface: "俺"},
+			want: []int{0, 3, 4, 6},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got := slices.Collect(x.Candidates(tt.data))
+			assert.Equal(tt.want, got)
+
+			// 候補を絞り込んでも、条件にマッチする変換ルールは漏らしませんわ
+			for i, c := range conds {
+				if c.MatchAllTokenData(tt.data) {
+					assert.Contains(got, i)
+				}
+			}
+		})
+	}
+}
+
+func TestRuleIndexCandidatesStop(t *testing.T) {
+	assert := assert.New(t)
+
+	x := NewRuleIndex([]ConvertConditions{{{Surface: "俺"}}, nil, {{Surface: "俺"}}})
+	var got []int
+	for i := range x.Candidates(tokenizer.TokenData{Surface: "俺"}) {
+		got = append(got, i)
+		if i == 1 {
+			break
+		}
+	}
+	assert.Equal([]int{0, 1}, got)
+}
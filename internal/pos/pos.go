@@ -21,4 +21,5 @@ var (
 	ConnAssistant             = []string{"助詞", "接続助詞"}
 	AuxiliaryVerb             = []string{"助動詞"}
 	NounsSaDynamic            = []string{"名詞", "サ変接続"}
+	AdverbParticleConn        = []string{"副詞", "助詞類接続"}
 )
@@ -47,6 +47,17 @@ func EqualsFeatures(a, b []string) bool {
 	return true
 }
 
+// HasPrefixFeatures は a の先頭の品詞が prefix と一致するかを判定する。
+//
+// a が prefix より短い場合は false を返す。
+// ユーザ辞書の単語のように品詞の数が少ない場合でも安全に判定できる。
+func HasPrefixFeatures(a, prefix []string) bool {
+	if len(a) < len(prefix) {
+		return false
+	}
+	return EqualsFeatures(a[:len(prefix)], prefix)
+}
+
 // ContainsFeatures は a の中に b が含まれるかを判定する。
 //
 // features用。
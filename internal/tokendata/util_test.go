@@ -110,6 +110,49 @@ func TestEqualsFeatures(t *testing.T) {
 	}
 }
 
+func TestHasPrefixFeatures(t *testing.T) {
+	tests := []struct {
+		desc   string
+		a      []string
+		prefix []string
+		want   bool
+	}{
+		{
+			desc:   "正常系: 先頭が一致すればtrueですわ",
+			a:      []string{"名詞", "固有名詞", "人名", "姓", "*"},
+			prefix: []string{"名詞", "固有名詞"},
+			want:   true,
+		},
+		{
+			desc:   "正常系: 先頭がずれていたらfalseですわ",
+			a:      []string{"名詞", "一般", "*", "*", "*"},
+			prefix: []string{"名詞", "固有名詞"},
+			want:   false,
+		},
+		{
+			desc:   "正常系: 品詞が少ない場合はfalseですわ",
+			a:      []string{"カスタム人名"},
+			prefix: []string{"名詞", "固有名詞"},
+			want:   false,
+		},
+		{
+			desc:   "正常系: 品詞が無い場合はfalseですわ",
+			a:      nil,
+			prefix: []string{"名詞", "固有名詞"},
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got := HasPrefixFeatures(tt.a, tt.prefix)
+			assert.Equal(tt.want, got)
+		})
+	}
+}
+
 func TestContainsFeatures(t *testing.T) {
 	tests := []struct {
 		desc string
@@ -0,0 +1,119 @@
+package ojosama
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// KutenWeight は句点を変換する時の候補と、その候補を選択する重み。
+//
+// 候補は重みの合計に対する割合で選択する。
+// 例えば {"。", 2}, {"！", 1}, {"❗", 1} の場合、
+// 50% で句点のまま、25% ずつで！と❗に変換する。
+type KutenWeight struct {
+	Value  string // 句点の代わりに出力する文字列。「。」の場合は句点のまま出力する
+	Weight int    // 重み。0 の場合は選択しない
+}
+
+var (
+	// defaultKutenWeights は句点を変換する時の候補。
+	defaultKutenWeights = []KutenWeight{
+		{Value: "。", Weight: 2},
+		{Value: "！", Weight: 1},
+		{Value: "❗", Weight: 1},
+	}
+
+	// extremeKutenWeights は LevelExtreme で句点を変換する時の候補。
+	extremeKutenWeights = []KutenWeight{
+		{Value: "！", Weight: 1},
+		{Value: "❗", Weight: 1},
+	}
+
+	// forcedKutenWeights は単体テストで句点を必ず❗に変換する時の候補。
+	forcedKutenWeights = []KutenWeight{
+		{Value: "❗", Weight: 1},
+	}
+)
+
+var errInvalidKutenWeight = errors.New("invalid kuten weight")
+
+// DefaultKutenWeights は変換の強さ level で句点を変換する時の候補を返す。
+//
+// 返却値は書き換えても変換結果には影響しない。
+// 句点を変換しない変換の強さの場合は nil を返す。
+func DefaultKutenWeights(level Level) []KutenWeight {
+	cfg, ok := levelConfigs[level]
+	if !ok {
+		cfg = levelConfigs[LevelStandard]
+	}
+	if cfg.kutenWeights == nil {
+		return nil
+	}
+	return append([]KutenWeight{}, cfg.kutenWeights...)
+}
+
+// validateKutenWeights は句点を変換する時の候補を検証する。
+//
+// 候補の文字列は空にできず、重みは負の値にできない。
+// 候補を指定する場合は、いずれかの候補の重みが1以上でなければならない。
+func validateKutenWeights(weights []KutenWeight) error {
+	if weights == nil {
+		return nil
+	}
+	var total int
+	for i, w := range weights {
+		if w.Value == "" {
+			return fmt.Errorf("%w: value of weight %d must not be empty", errInvalidKutenWeight, i)
+		}
+		if w.Weight < 0 {
+			return fmt.Errorf("%w: %q=%d", errInvalidKutenWeight, w.Value, w.Weight)
+		}
+		total += w.Weight
+	}
+	if total < 1 {
+		return fmt.Errorf("%w: total must be positive", errInvalidKutenWeight)
+	}
+	return nil
+}
+
+// kutenWeightsOf は opt の設定で句点を変換する時の候補を返す。
+//
+// opt に KutenWeights がある場合は変換の強さの設定よりも優先する。
+func kutenWeightsOf(opt *ConvertOption) []KutenWeight {
+	if opt != nil {
+		// テスト用に値をすげ替えられるようにする
+		if opt.forceKutenToExclamation {
+			return forcedKutenWeights
+		}
+		if opt.KutenWeights != nil {
+			return opt.KutenWeights
+		}
+	}
+	return levelConfigOf(opt).kutenWeights
+}
+
+// sampleKuten は重みに従って候補をランダムに1つ選択する。
+//
+// 選択できる候補が無い場合は false を返す。
+func sampleKuten(weights []KutenWeight, rnd *rand.Rand) (string, bool) {
+	var total int
+	for _, w := range weights {
+		total += w.Weight
+	}
+	if total < 1 {
+		return "", false
+	}
+
+	// 複数のゴルーチンから同時に呼ばれるため、共有のスライスは並び替えずに
+	// 重みの累積から選択する
+	n := rnd.Intn(total)
+	for _, w := range weights {
+		n -= w.Weight
+		if n < 0 {
+			return w.Value, true
+		}
+	}
+	// 到達しないはずだけれど一応いれてる
+	return "", false
+}
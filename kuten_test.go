@@ -0,0 +1,119 @@
+package ojosama
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertKutenWeightsDistribution(t *testing.T) {
+	const n = 4000
+	src := strings.Repeat("ハーブです。", n)
+
+	tests := []struct {
+		desc string
+		opt  *ConvertOption
+		want map[string]float64
+	}{
+		{
+			desc: "正常系: 標準の場合は半分は句点のまま、残りを！と❗に半分ずつ変換いたしますわ",
+			opt:  &ConvertOption{},
+			want: map[string]float64{"。": 0.5, "！": 0.25, "❗": 0.25},
+		},
+		{
+			desc: "正常系: 過激の場合は！と❗に半分ずつ変換いたしますわ",
+			opt:  &ConvertOption{Level: LevelExtreme},
+			want: map[string]float64{"。": 0, "！": 0.5, "❗": 0.5},
+		},
+		{
+			desc: "正常系: 重みを指定した場合は重みの割合で変換いたしますわ",
+			opt: &ConvertOption{
+				KutenWeights: []KutenWeight{
+					{Value: "。", Weight: 1},
+					{Value: "！", Weight: 3},
+					{Value: "❗", Weight: 0},
+				},
+			},
+			want: map[string]float64{"。": 0.25, "！": 0.75, "❗": 0},
+		},
+		{
+			desc: "正常系: 控えめの場合でも重みを指定した場合は変換いたしますわ",
+			opt: &ConvertOption{
+				Level:        LevelSubtle,
+				KutenWeights: []KutenWeight{{Value: "❗", Weight: 1}},
+			},
+			want: map[string]float64{"。": 0, "！": 0, "❗": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			seed := int64(1)
+			opt := *tt.opt
+			opt.Seed = &seed
+			got, err := Convert(src, &opt)
+			assert.NoError(err)
+
+			var total int
+			for s, want := range tt.want {
+				c := strings.Count(got, s)
+				total += c
+				assert.InDelta(want, float64(c)/n, 0.03, s)
+			}
+			assert.Equal(n, total)
+		})
+	}
+}
+
+func TestDefaultKutenWeights(t *testing.T) {
+	assert := assert.New(t)
+
+	got := DefaultKutenWeights(LevelStandard)
+	assert.Equal([]KutenWeight{{"。", 2}, {"！", 1}, {"❗", 1}}, got)
+
+	// 返却値を書き換えても既定の候補には影響しない
+	got[0].Weight = 100
+	assert.Equal(2, DefaultKutenWeights(LevelStandard)[0].Weight)
+
+	assert.Nil(DefaultKutenWeights(LevelSubtle))
+}
+
+func TestConvertInvalidKutenWeights(t *testing.T) {
+	tests := []struct {
+		desc    string
+		weights []KutenWeight
+	}{
+		{
+			desc:    "異常系: 空の候補はエラーですわ",
+			weights: []KutenWeight{{Value: "", Weight: 1}},
+		},
+		{
+			desc:    "異常系: 負の重みはエラーですわ",
+			weights: []KutenWeight{{Value: "！", Weight: -1}, {Value: "❗", Weight: 2}},
+		},
+		{
+			desc:    "異常系: 重みの合計が0の場合はエラーですわ",
+			weights: []KutenWeight{{Value: "！", Weight: 0}},
+		},
+		{
+			desc:    "異常系: 空の候補の一覧はエラーですわ",
+			weights: []KutenWeight{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			opt := &ConvertOption{KutenWeights: tt.weights}
+			_, err := Convert("ハーブです。", opt)
+			assert.ErrorIs(err, errInvalidKutenWeight)
+
+			_, err = NewConverter(opt)
+			assert.ErrorIs(err, errInvalidKutenWeight)
+		})
+	}
+}
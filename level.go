@@ -0,0 +1,118 @@
+package ojosama
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jiro4989/ojosama/internal/converter"
+)
+
+// Level はお嬢様言葉への変換の強さ。
+//
+// 変換の強さによって、有効にする変換ルールの分類、「お」の付与の仕方、
+// 波線や感嘆符の数、句点を！に変換する確率を切り替える。
+type Level int
+
+const (
+	LevelStandard Level = iota // 標準。ゼロ値のためデフォルトの変換の強さになる
+	LevelSubtle                // 控えめ。お客様向けの文章など、装飾を抑えたい場合に使う
+	LevelExtreme               // 過激。冗談で使う場合など、とにかくお嬢様にしたい場合に使う
+)
+
+var levelNames = map[Level]string{
+	LevelStandard: "standard",
+	LevelSubtle:   "subtle",
+	LevelExtreme:  "extreme",
+}
+
+// String は変換の強さの名前を返す。
+func (l Level) String() string {
+	if s, ok := levelNames[l]; ok {
+		return s
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// ParseLevel は名前に対応する変換の強さを返す。
+func ParseLevel(s string) (Level, error) {
+	for k, v := range levelNames {
+		if v == s {
+			return k, nil
+		}
+	}
+	return LevelStandard, fmt.Errorf("%w: %q", errUnknownLevel, s)
+}
+
+var errUnknownLevel = errors.New("unknown level")
+
+// validate は変換の強さが既知の値かを検証する。
+func (l Level) validate() error {
+	if _, ok := levelNames[l]; !ok {
+		return fmt.Errorf("%w: %d", errUnknownLevel, int(l))
+	}
+	return nil
+}
+
+// prefixMode は「お」の付与の仕方。
+type prefixMode int
+
+const (
+	prefixModeStandard   prefixMode = iota // 一般名詞と固有名詞に付与する
+	prefixModeNone                         // 付与しない
+	prefixModeAggressive                   // サ変接続の名詞や、動詞が続く名詞にも付与する
+)
+
+// levelConfig は変換の強さごとの設定。
+type levelConfig struct {
+	disabledCategories []converter.Category // 無効にする変換ルールの分類
+	prefix             prefixMode           // 「お」の付与の仕方
+	disableLongNote    bool                 // 波線や感嘆符を付与しない
+	longNote           LongNoteConfig       // 波線や感嘆符の付け方
+	kutenWeights       []KutenWeight        // 句点を変換する時の候補。nil の場合は変換しない
+}
+
+var levelConfigs = map[Level]*levelConfig{
+	LevelStandard: {
+		prefix: prefixModeStandard,
+		longNote: LongNoteConfig{
+			WavyLine:      defaultWavyLine,
+			WavyLineCount: CountRange{Min: 0, Max: 2},
+			MarkCount:     CountRange{Min: 0, Max: 2},
+		},
+		kutenWeights: defaultKutenWeights,
+	},
+	LevelSubtle: {
+		disabledCategories: []converter.Category{
+			converter.CategoryInterjection,
+			converter.CategoryVulgar,
+		},
+		prefix:          prefixModeNone,
+		disableLongNote: true,
+		// 波線や感嘆符は付与しないけれど、LongNote で付与する場合の雛形として標準と同じ設定にしておく
+		longNote: LongNoteConfig{
+			WavyLine:      defaultWavyLine,
+			WavyLineCount: CountRange{Min: 0, Max: 2},
+			MarkCount:     CountRange{Min: 0, Max: 2},
+		},
+	},
+	LevelExtreme: {
+		prefix: prefixModeAggressive,
+		longNote: LongNoteConfig{
+			WavyLine:      defaultWavyLine,
+			WavyLineCount: CountRange{Min: 1, Max: 3},
+			MarkCount:     CountRange{Min: 2, Max: 4},
+		},
+		kutenWeights: extremeKutenWeights,
+	},
+}
+
+// levelConfigOf は opt の変換の強さの設定を返す。
+func levelConfigOf(opt *ConvertOption) *levelConfig {
+	if opt == nil {
+		return levelConfigs[LevelStandard]
+	}
+	if cfg, ok := levelConfigs[opt.Level]; ok {
+		return cfg
+	}
+	return levelConfigs[LevelStandard]
+}
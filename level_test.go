@@ -0,0 +1,174 @@
+package ojosama
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertLevel(t *testing.T) {
+	tests := []struct {
+		desc string
+		src  string
+		opt  *ConvertOption
+		want string
+	}{
+		{
+			desc: "正常系: 控えめの場合は「お」を付与しませんわ",
+			src:  "俺はハーブを勉強します。",
+			opt:  &ConvertOption{Level: LevelSubtle},
+			want: "私はハーブを勉強いたしますわ。",
+		},
+		{
+			desc: "正常系: 控えめの場合は感動詞と下品な言葉を変換しませんわ",
+			src:  "うふふ、臭いです",
+			opt:  &ConvertOption{Level: LevelSubtle},
+			want: "うふふ、臭いですわ",
+		},
+		{
+			desc: "正常系: 標準の場合は感動詞と下品な言葉も変換いたしますわ",
+			src:  "うふふ、臭いです",
+			opt:  &ConvertOption{Level: LevelStandard},
+			want: "おほほ、くっせぇですわ",
+		},
+		{
+			desc: "正常系: 過激の場合はサ変接続の名詞にも「お」を付与いたしますわ",
+			src:  "俺はハーブを勉強する",
+			opt:  &ConvertOption{Level: LevelExtreme},
+			want: "私はおハーブをお勉強いたしますわ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := Convert(tt.src, tt.opt)
+			assert.NoError(err)
+			assert.Equal(tt.want, got)
+		})
+	}
+}
+
+func TestConvertLevelLongNote(t *testing.T) {
+	tests := []struct {
+		desc      string
+		level     Level
+		wantWavy  bool
+		wantPlain bool
+	}{
+		{
+			desc:      "正常系: 控えめの場合は波線を付与しませんわ",
+			level:     LevelSubtle,
+			wantPlain: true,
+		},
+		{
+			desc:     "正常系: 過激の場合は必ず波線を付与いたしますわ",
+			level:    LevelExtreme,
+			wantWavy: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// 乱数に依存するため、シード値を変えて何度か確認する
+			for i := int64(0); i < 20; i++ {
+				seed := i
+				got, err := Convert("ハーブです！", &ConvertOption{Level: tt.level, Seed: &seed})
+				assert.NoError(err)
+				if tt.wantPlain {
+					assert.Equal("ハーブですわ！", got)
+				}
+				if tt.wantWavy {
+					assert.Contains(got, "～")
+				}
+			}
+		})
+	}
+}
+
+func TestConvertLevelKutenToExclamation(t *testing.T) {
+	tests := []struct {
+		desc  string
+		level Level
+		want  []string
+	}{
+		{
+			desc:  "正常系: 控えめの場合は句点を！に変換しませんわ",
+			level: LevelSubtle,
+			want:  []string{"ハーブですわ。"},
+		},
+		{
+			desc:  "正常系: 過激の場合は句点を必ず！に変換いたしますわ",
+			level: LevelExtreme,
+			want:  []string{"おハーブですわ！", "おハーブですわ❗"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			for i := int64(0); i < 20; i++ {
+				seed := i
+				got, err := Convert("ハーブです。", &ConvertOption{Level: tt.level, Seed: &seed})
+				assert.NoError(err)
+				assert.Contains(tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		desc    string
+		s       string
+		want    Level
+		wantErr bool
+	}{
+		{
+			desc:    "正常系: subtle ですわ",
+			s:       "subtle",
+			want:    LevelSubtle,
+			wantErr: false,
+		},
+		{
+			desc:    "正常系: String の結果から元の変換の強さを取得できますわ",
+			s:       LevelExtreme.String(),
+			want:    LevelExtreme,
+			wantErr: false,
+		},
+		{
+			desc:    "異常系: 不明な名前はエラーですわ",
+			s:       "hoge",
+			want:    LevelStandard,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := ParseLevel(tt.s)
+			if tt.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tt.want, got)
+		})
+	}
+}
+
+func TestConvertUnknownLevel(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := Convert("ハーブです", &ConvertOption{Level: Level(100)})
+	assert.ErrorIs(err, errUnknownLevel)
+
+	_, err = NewConverter(&ConvertOption{Level: Level(100)})
+	assert.ErrorIs(err, errUnknownLevel)
+}
@@ -0,0 +1,121 @@
+package ojosama
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/ikawaha/kagome/v2/tokenizer"
+	"github.com/jiro4989/ojosama/internal/chars"
+)
+
+// LongNoteConfig は感嘆符や疑問符の前に付与する波線や感嘆符の付け方の設定。
+//
+// 例えば波線の数が2、感嘆符の数が3の場合は「ハーブです！」を「おハーブですわ～～！！！」に変換する。
+type LongNoteConfig struct {
+	// 波線の文字。「～」「〜」「ー」など。
+	// 空の場合は「～」を使う。
+	WavyLine string
+
+	// 付与する波線の数の範囲。
+	WavyLineCount CountRange
+
+	// 感嘆符や疑問符の数の範囲。
+	// 入力の感嘆符や疑問符1つ分を含むため、1以下の場合は入力の感嘆符や疑問符だけになる。
+	MarkCount CountRange
+
+	// 入力で感嘆符や疑問符が連続している場合に、連続している数に応じて
+	// 波線と感嘆符や疑問符を増やす。
+	// 例えば入力が「！！！」の場合は、波線と感嘆符を2つずつ増やす。
+	ScaleWithInput bool
+}
+
+// CountRange は乱数で選択する数の範囲。Min と Max を含む。
+type CountRange struct {
+	Min int
+	Max int
+}
+
+// defaultWavyLine は波線の文字を指定しなかった時に使う波線。
+const defaultWavyLine = "～"
+
+// FixedLongNote は波線の数と感嘆符や疑問符の数を固定した設定を返す。
+//
+// 波線や感嘆符の付与には乱数が絡むため、変換結果を固定したい場合に使う。
+func FixedLongNote(wavyLineCount, markCount int) *LongNoteConfig {
+	return &LongNoteConfig{
+		WavyLineCount: CountRange{Min: wavyLineCount, Max: wavyLineCount},
+		MarkCount:     CountRange{Min: markCount, Max: markCount},
+	}
+}
+
+// DefaultLongNoteConfig は変換の強さ level で使う波線や感嘆符の付け方の設定を返す。
+//
+// 一部の設定だけを変更したい場合に、返却値を書き換えて ConvertOption の LongNote に指定する。
+func DefaultLongNoteConfig(level Level) LongNoteConfig {
+	if cfg, ok := levelConfigs[level]; ok {
+		return cfg.longNote
+	}
+	return levelConfigs[LevelStandard].longNote
+}
+
+var errInvalidLongNote = errors.New("invalid long note config")
+
+// validate は数の範囲が正しいかを検証する。
+func (r CountRange) validate() error {
+	if r.Min < 0 || r.Max < r.Min {
+		return fmt.Errorf("%w: range %d-%d", errInvalidLongNote, r.Min, r.Max)
+	}
+	return nil
+}
+
+// validate は波線や感嘆符の付け方の設定が正しいかを検証する。nil は問題ない。
+func (c *LongNoteConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+	if err := c.WavyLineCount.validate(); err != nil {
+		return err
+	}
+	return c.MarkCount.validate()
+}
+
+// sample は範囲内の数をランダムに選択する。
+//
+// 範囲が1つの数だけの場合は乱数を使わない。
+func (r CountRange) sample(rnd *rand.Rand) int {
+	if r.Max <= r.Min {
+		return r.Min
+	}
+	return r.Min + rnd.Intn(r.Max-r.Min+1)
+}
+
+// longNoteConfigOf は opt の設定での波線や感嘆符の付け方の設定を返す。
+//
+// opt に LongNote がある場合は変換の強さの設定よりも優先する。
+func longNoteConfigOf(opt *ConvertOption) LongNoteConfig {
+	var cfg LongNoteConfig
+	if opt != nil && opt.LongNote != nil {
+		cfg = *opt.LongNote
+	} else {
+		cfg = levelConfigOf(opt).longNote
+	}
+	if cfg.WavyLine == "" {
+		cfg.WavyLine = defaultWavyLine
+	}
+	return cfg
+}
+
+// countContinuousMarks は i 番目より後ろに連続する感嘆符や疑問符の数を返す。
+func countContinuousMarks(tokens []tokenizer.TokenData, i int) int {
+	var n int
+	for j := i + 1; j < len(tokens); j++ {
+		for _, r := range tokens[j].Surface {
+			if ok, _ := chars.IsExclamationQuestionMark(string(r)); !ok {
+				return n
+			}
+			n++
+		}
+	}
+	return n
+}
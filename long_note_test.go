@@ -0,0 +1,128 @@
+package ojosama
+
+import (
+	"testing"
+
+	"github.com/jiro4989/ojosama/internal/chars"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertLongNote(t *testing.T) {
+	tests := []struct {
+		desc string
+		src  string
+		opt  *ConvertOption
+		want string
+	}{
+		{
+			desc: "正常系: 波線の文字を変更できますわ",
+			src:  "ハーブです！",
+			opt: &ConvertOption{
+				LongNote: &LongNoteConfig{
+					WavyLine:      "〜",
+					WavyLineCount: CountRange{Min: 2, Max: 2},
+					MarkCount:     CountRange{Min: 2, Max: 2},
+				},
+			},
+			want: "おハーブですわ〜〜！！",
+		},
+		{
+			desc: "正常系: 入力の感嘆符が連続している数に応じて伸ばしますわ",
+			src:  "ハーブです！！！",
+			opt: &ConvertOption{
+				LongNote: &LongNoteConfig{
+					WavyLineCount:  CountRange{Min: 1, Max: 1},
+					MarkCount:      CountRange{Min: 1, Max: 1},
+					ScaleWithInput: true,
+				},
+			},
+			want: "おハーブですわ～～～！！！！！",
+		},
+		{
+			desc: "正常系: 入力に合わせない場合は入力の感嘆符の数だけですわ",
+			src:  "ハーブです！！！",
+			opt:  &ConvertOption{LongNote: FixedLongNote(1, 1)},
+			want: "おハーブですわ～！！！",
+		},
+		{
+			desc: "正常系: 指定した場合は控えめでも付与いたしますわ",
+			src:  "ハーブです！",
+			opt:  &ConvertOption{Level: LevelSubtle, LongNote: FixedLongNote(1, 2)},
+			want: "ハーブですわ～！！",
+		},
+		{
+			desc: "正常系: 機能を OFF にした場合は指定しても付与いたしませんわ",
+			src:  "ハーブです！",
+			opt:  &ConvertOption{DisableLongNote: true, LongNote: FixedLongNote(1, 2)},
+			want: "おハーブですわ！",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// 乱数に依存しないことを、シード値を変えて何度か確認する
+			for i := int64(0); i < 10; i++ {
+				seed := i
+				opt := *tt.opt
+				opt.Seed = &seed
+				opt.forceCharsTestMode = &chars.TestMode{Pos: 0}
+				got, err := Convert(tt.src, &opt)
+				assert.NoError(err)
+				assert.Equal(tt.want, got)
+			}
+		})
+	}
+}
+
+func TestConvertLongNoteRange(t *testing.T) {
+	assert := assert.New(t)
+
+	// 指定した範囲の数だけ付与いたしますわ
+	cfg := DefaultLongNoteConfig(LevelExtreme)
+	assert.Equal(CountRange{Min: 1, Max: 3}, cfg.WavyLineCount)
+	cfg.WavyLine = "ー"
+	cfg.WavyLineCount = CountRange{Min: 1, Max: 2}
+	seen := map[string]bool{}
+	for i := int64(0); i < 50; i++ {
+		seed := i
+		got, err := Convert("ハーブです！", &ConvertOption{
+			LongNote:           &cfg,
+			Seed:               &seed,
+			forceCharsTestMode: &chars.TestMode{Pos: 0},
+		})
+		assert.NoError(err)
+		assert.Regexp(`^おハーブですわー{1,2}！{2,4}$`, got)
+		seen[got] = true
+	}
+	assert.Less(1, len(seen))
+}
+
+func TestConvertInvalidLongNote(t *testing.T) {
+	tests := []struct {
+		desc     string
+		longNote *LongNoteConfig
+	}{
+		{
+			desc:     "異常系: 負の数はエラーですわ",
+			longNote: &LongNoteConfig{WavyLineCount: CountRange{Min: -1, Max: 1}},
+		},
+		{
+			desc:     "異常系: 最大値が最小値より小さい場合はエラーですわ",
+			longNote: &LongNoteConfig{MarkCount: CountRange{Min: 3, Max: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			_, err := Convert("ハーブです！", &ConvertOption{LongNote: tt.longNote})
+			assert.ErrorIs(err, errInvalidLongNote)
+
+			_, err = NewConverter(&ConvertOption{LongNote: tt.longNote})
+			assert.ErrorIs(err, errInvalidLongNote)
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package ojosama
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/ikawaha/kagome/v2/tokenizer"
+	"github.com/jiro4989/ojosama/internal/chars"
+)
+
+// MarkStyle は波線の後ろや句点の代わりに付与する感嘆符・疑問符のスタイル。
+type MarkStyle int
+
+const (
+	MarkStyleRandom      MarkStyle = iota // ランダム。ゼロ値のためデフォルトのスタイルになる
+	MarkStyleFullWidth                    // 全角。！や？
+	MarkStyleHalfWidth                    // 半角。!や?
+	MarkStyleEmoji                        // 絵文字。❗や❓
+	MarkStyleDoubleEmoji                  // 二重の絵文字。‼ 。疑問符は絵文字で代用する
+	MarkStyleInput                        // 入力の文章の感嘆符・疑問符のスタイルに合わせる
+)
+
+var markStyleNames = map[MarkStyle]string{
+	MarkStyleRandom:      "random",
+	MarkStyleFullWidth:   "fullwidth",
+	MarkStyleHalfWidth:   "halfwidth",
+	MarkStyleEmoji:       "emoji",
+	MarkStyleDoubleEmoji: "double_emoji",
+	MarkStyleInput:       "input",
+}
+
+// markStyleChars は具体的なスタイルと文字のスタイルの対応。
+//
+// 重みに従ってスタイルを選択する時の順序を固定するためにスライスにしている。
+var markStyleChars = []struct {
+	style MarkStyle
+	chars chars.StyleType
+}{
+	{MarkStyleFullWidth, chars.StyleTypeFullWidth},
+	{MarkStyleHalfWidth, chars.StyleTypeHalfWidth},
+	{MarkStyleEmoji, chars.StyleTypeEmoji},
+	{MarkStyleDoubleEmoji, chars.StyleTypeDoubleEmoji},
+}
+
+// String はスタイルの名前を返す。
+func (s MarkStyle) String() string {
+	if v, ok := markStyleNames[s]; ok {
+		return v
+	}
+	return fmt.Sprintf("MarkStyle(%d)", int(s))
+}
+
+// ParseMarkStyle は名前に対応するスタイルを返す。
+func ParseMarkStyle(s string) (MarkStyle, error) {
+	for k, v := range markStyleNames {
+		if v == s {
+			return k, nil
+		}
+	}
+	return MarkStyleRandom, fmt.Errorf("%w: %q", errUnknownMarkStyle, s)
+}
+
+var (
+	errUnknownMarkStyle       = errors.New("unknown mark style")
+	errInvalidMarkStyleWeight = errors.New("invalid mark style weight")
+)
+
+// validate はスタイルが既知の値かを検証する。
+func (s MarkStyle) validate() error {
+	if _, ok := markStyleNames[s]; !ok {
+		return fmt.Errorf("%w: %d", errUnknownMarkStyle, int(s))
+	}
+	return nil
+}
+
+// toChars はスタイルを文字のスタイルに変換する。
+//
+// 具体的なスタイルでない場合は false を返す。
+func (s MarkStyle) toChars() (chars.StyleType, bool) {
+	for _, v := range markStyleChars {
+		if v.style == s {
+			return v.chars, true
+		}
+	}
+	return chars.StyleTypeUnknown, false
+}
+
+// validateMarkStyleWeights はスタイルごとの重みを検証する。
+//
+// 重みは具体的なスタイルにだけ指定でき、負の値は指定できない。
+// 重みを指定する場合は、いずれかのスタイルの重みが1以上でなければならない。
+func validateMarkStyleWeights(weights map[MarkStyle]int) error {
+	if len(weights) < 1 {
+		return nil
+	}
+	var total int
+	for s, w := range weights {
+		if _, ok := s.toChars(); !ok {
+			return fmt.Errorf("%w: %s", errInvalidMarkStyleWeight, s)
+		}
+		if w < 0 {
+			return fmt.Errorf("%w: %s=%d", errInvalidMarkStyleWeight, s, w)
+		}
+		total += w
+	}
+	if total < 1 {
+		return fmt.Errorf("%w: total must be positive", errInvalidMarkStyleWeight)
+	}
+	return nil
+}
+
+// sampleMarkStyle は重みに従ってスタイルをランダムに1つ選択する。
+func sampleMarkStyle(weights map[MarkStyle]int, rnd *rand.Rand) MarkStyle {
+	var total int
+	for _, v := range markStyleChars {
+		total += weights[v.style]
+	}
+	n := rnd.Intn(total)
+	for _, v := range markStyleChars {
+		n -= weights[v.style]
+		if n < 0 {
+			return v.style
+		}
+	}
+	// 到達しないはずだけれど一応いれてる
+	return MarkStyleFullWidth
+}
+
+// resolveMarkStyle は opt の設定で使うスタイルを返す。
+//
+// 重みが指定されている場合は、重みに従ってスタイルを選択する。
+func resolveMarkStyle(opt *ConvertOption, rnd *rand.Rand) MarkStyle {
+	if opt == nil {
+		return MarkStyleRandom
+	}
+	if opt.MarkStyle == MarkStyleRandom && 0 < len(opt.MarkStyleWeights) {
+		return sampleMarkStyle(opt.MarkStyleWeights, rnd)
+	}
+	return opt.MarkStyle
+}
+
+// longNoteMark は波線の後ろに付与する、s と同じ意味の感嘆符・疑問符を返す。
+//
+// s は入力の文章の感嘆符・疑問符。
+func longNoteMark(s string, opt *ConvertOption, rnd *rand.Rand) *chars.ExclamationQuestionMark {
+	var tm *chars.TestMode
+	if opt != nil {
+		tm = opt.forceCharsTestMode
+	}
+
+	style := resolveMarkStyle(opt, rnd)
+	if style == MarkStyleRandom {
+		// ！or？をどれかからランダムに選択する
+		return chars.SampleExclamationQuestionByValue(s, rnd, tm)
+	}
+
+	_, eq := chars.IsExclamationQuestionMark(s)
+	if style == MarkStyleInput {
+		return eq
+	}
+	st, _ := style.toChars()
+	if got := chars.FindExclamationQuestionByStyleAndMeaningWithFallback(st, eq.Meaning); got != nil {
+		return got
+	}
+	return eq
+}
+
+// kutenMark は句点を変換する時の候補 v を opt の設定のスタイルに置き換える。
+//
+// v が感嘆符でない場合はそのまま返す。
+// 入力の文章のスタイルに合わせる場合は、文章中の最初の感嘆符・疑問符のスタイルを使う。
+// 文章中に感嘆符・疑問符が無い場合は、句点に合わせて全角にする。
+func kutenMark(v string, tokens []tokenizer.TokenData, opt *ConvertOption, rnd *rand.Rand) string {
+	ok, eq := chars.IsExclamationQuestionMark(v)
+	if !ok {
+		return v
+	}
+
+	var st chars.StyleType
+	switch style := resolveMarkStyle(opt, rnd); style {
+	case MarkStyleRandom:
+		return v
+	case MarkStyleInput:
+		st = inputMarkStyle(tokens)
+	default:
+		st, _ = style.toChars()
+	}
+	if got := chars.FindExclamationQuestionByStyleAndMeaningWithFallback(st, eq.Meaning); got != nil {
+		return got.Value
+	}
+	return v
+}
+
+// inputMarkStyle は文章中の最初の感嘆符・疑問符のスタイルを返す。
+//
+// 感嘆符・疑問符が無い場合は全角を返す。
+func inputMarkStyle(tokens []tokenizer.TokenData) chars.StyleType {
+	for _, token := range tokens {
+		for _, r := range token.Surface {
+			if ok, eq := chars.IsExclamationQuestionMark(string(r)); ok {
+				return eq.Style
+			}
+		}
+	}
+	return chars.StyleTypeFullWidth
+}
@@ -0,0 +1,175 @@
+package ojosama
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertMarkStyle(t *testing.T) {
+	longNote := FixedLongNote(1, 2)
+
+	tests := []struct {
+		desc string
+		src  string
+		opt  *ConvertOption
+		want string
+	}{
+		{
+			desc: "正常系: 全角に固定した場合は全角の感嘆符を付与いたしますわ",
+			src:  "ハーブです!",
+			opt:  &ConvertOption{MarkStyle: MarkStyleFullWidth, LongNote: longNote},
+			want: "おハーブですわ～！！",
+		},
+		{
+			desc: "正常系: 半角に固定した場合は半角の感嘆符を付与いたしますわ",
+			src:  "ハーブです！",
+			opt:  &ConvertOption{MarkStyle: MarkStyleHalfWidth, LongNote: longNote},
+			want: "おハーブですわ～!!",
+		},
+		{
+			desc: "正常系: 絵文字に固定した場合は絵文字の疑問符を付与いたしますわ",
+			src:  "ハーブです？",
+			opt:  &ConvertOption{MarkStyle: MarkStyleEmoji, LongNote: longNote},
+			want: "おハーブですわ～❓❓",
+		},
+		{
+			desc: "正常系: 二重の絵文字の疑問符は絵文字で代用いたしますわ",
+			src:  "ハーブです！？",
+			opt:  &ConvertOption{MarkStyle: MarkStyleDoubleEmoji, LongNote: longNote},
+			want: "おハーブですわ～‼‼❓",
+		},
+		{
+			desc: "正常系: 入力のスタイルに合わせる場合は入力の感嘆符をそのまま使いますわ",
+			src:  "ハーブです!？",
+			opt:  &ConvertOption{MarkStyle: MarkStyleInput, LongNote: longNote},
+			want: "おハーブですわ～!!？",
+		},
+		{
+			desc: "正常系: 重みが0のスタイルは選択いたしませんわ",
+			src:  "ハーブです！",
+			opt: &ConvertOption{
+				MarkStyleWeights: map[MarkStyle]int{MarkStyleFullWidth: 0, MarkStyleEmoji: 1},
+				LongNote:         longNote,
+			},
+			want: "おハーブですわ～❗❗",
+		},
+		{
+			desc: "正常系: 句点を変換する時も固定したスタイルを使いますわ",
+			src:  "ハーブです。",
+			opt:  &ConvertOption{MarkStyle: MarkStyleHalfWidth, forceKutenToExclamation: true},
+			want: "おハーブですわ!",
+		},
+		{
+			desc: "正常系: 句点を変換する時に入力のスタイルに合わせる場合は文章中の感嘆符に合わせますわ",
+			src:  "ハーブです。ハーブ!",
+			opt:  &ConvertOption{MarkStyle: MarkStyleInput, forceKutenToExclamation: true},
+			want: "おハーブですわ!おハーブ!",
+		},
+		{
+			desc: "正常系: 文章中に感嘆符が無い場合は全角に合わせますわ",
+			src:  "ハーブです。",
+			opt:  &ConvertOption{MarkStyle: MarkStyleInput, forceKutenToExclamation: true},
+			want: "おハーブですわ！",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// 乱数に依存しないことを、シード値を変えて何度か確認する
+			for i := int64(0); i < 10; i++ {
+				seed := i
+				opt := *tt.opt
+				opt.Seed = &seed
+				got, err := Convert(tt.src, &opt)
+				assert.NoError(err)
+				assert.Equal(tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseMarkStyle(t *testing.T) {
+	tests := []struct {
+		desc    string
+		s       string
+		want    MarkStyle
+		wantErr bool
+	}{
+		{
+			desc:    "正常系: emoji ですわ",
+			s:       "emoji",
+			want:    MarkStyleEmoji,
+			wantErr: false,
+		},
+		{
+			desc:    "正常系: String の結果から元のスタイルを取得できますわ",
+			s:       MarkStyleDoubleEmoji.String(),
+			want:    MarkStyleDoubleEmoji,
+			wantErr: false,
+		},
+		{
+			desc:    "異常系: 不明な名前はエラーですわ",
+			s:       "hoge",
+			want:    MarkStyleRandom,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := ParseMarkStyle(tt.s)
+			if tt.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tt.want, got)
+		})
+	}
+}
+
+func TestConvertInvalidMarkStyle(t *testing.T) {
+	tests := []struct {
+		desc    string
+		opt     *ConvertOption
+		wantErr error
+	}{
+		{
+			desc:    "異常系: 不明なスタイルはエラーですわ",
+			opt:     &ConvertOption{MarkStyle: MarkStyle(100)},
+			wantErr: errUnknownMarkStyle,
+		},
+		{
+			desc:    "異常系: 具体的なスタイル以外の重みはエラーですわ",
+			opt:     &ConvertOption{MarkStyleWeights: map[MarkStyle]int{MarkStyleInput: 1}},
+			wantErr: errInvalidMarkStyleWeight,
+		},
+		{
+			desc:    "異常系: 負の重みはエラーですわ",
+			opt:     &ConvertOption{MarkStyleWeights: map[MarkStyle]int{MarkStyleEmoji: -1, MarkStyleFullWidth: 2}},
+			wantErr: errInvalidMarkStyleWeight,
+		},
+		{
+			desc:    "異常系: 重みの合計が0の場合はエラーですわ",
+			opt:     &ConvertOption{MarkStyleWeights: map[MarkStyle]int{MarkStyleEmoji: 0}},
+			wantErr: errInvalidMarkStyleWeight,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			_, err := Convert("ハーブです", tt.opt)
+			assert.ErrorIs(err, tt.wantErr)
+
+			_, err = NewConverter(tt.opt)
+			assert.ErrorIs(err, tt.wantErr)
+		})
+	}
+}
@@ -3,17 +3,17 @@ ojosama はお嬢様言葉への変換ロジックを管理する。
 
 関数や構造体のアクセス範囲に関する方針
 
-基本的にライブラリ用途としては Convert 関数1つのみを公開する。
+基本的にライブラリ用途としては Convert 関数と Converter 構造体のみを公開する。
 Convert関数の挙動の微調整はConvertOption構造体で制御する。
+同じ設定で何度も変換する場合は、形態素解析器の初期化コストを避けるために
+NewConverter で生成した Converter を使い回す。
 
-ユーザ側で独自に変換ルールを追加出来たほうが良いかもしれないが、
-パッケージ構成や型名を変更する可能性が高いため、
-破壊的変更として影響しないように公開する機能を必要最小限にする。
+ライブラリ呼び出し側で独自に変換ルールを追加したいという要望があったため、
+変換ルールは Rule などの公開用の型で定義して ConvertOption で追加できるようにしている。
+公開用の型は internal/converter の型とは別に定義し、
+内部の型名やパッケージ構成を変更しても影響しないようにしている。
 
-「ライブラリ呼び出し側で独自に変換ルールを追加したい」という人は
-おそらく現れないと想定しているが、要望がきた時に改めて考える。
-
-それまでは好き勝手パッケージ構成や名前を変更しまくれるように
+それ以外は好き勝手パッケージ構成や名前を変更しまくれるように
 公開範囲を極小にする。
 ゆえにConvert関数内の処理で関数を分割したくなった場合、
 すべてプライベート関数として実装する。
@@ -34,11 +34,12 @@ Convert関数の挙動の微調整はConvertOption構造体で制御する。
 package ojosama
 
 import (
+	"context"
 	"math/rand"
 	"regexp"
 	"strings"
 
-	"github.com/ikawaha/kagome-dict/ipa"
+	"github.com/ikawaha/kagome-dict/dict"
 	"github.com/ikawaha/kagome/v2/tokenizer"
 	"github.com/jiro4989/ojosama/internal/chars"
 	"github.com/jiro4989/ojosama/internal/converter"
@@ -54,21 +55,104 @@ type ConvertOption struct {
 	// オプションパラメータで無効にできるようにする。
 	DisableKutenToExclamation bool
 
-	forceAppendLongNote     forceAppendLongNote // 単体テスト用のパラメータ
-	forceCharsTestMode      *chars.TestMode     // 単体テスト用のパラメータ
-	forceKutenToExclamation bool                // KutenToExclamationで強制的に3番目の要素を選択する
-}
-
-// forceAppendLongNote は強制的に波線や感嘆符や疑問符を任意の数追加するための設定。
-//
-// 波線や感嘆符の付与には乱数が絡むため、単体テスト実行時に確実に等しい結果を得
-// ることが難しい。この問題を回避するために、このパラメータを差し込むことで乱数
-// の影響を受けないように制御する。単体テストでしか使うことを想定していないため、
-// パブリックにはしない。
-type forceAppendLongNote struct {
-	enable               bool
-	wavyLineCount        int
-	exclamationMarkCount int
+	// すでにお嬢様言葉になっている箇所も変換する。
+	// デフォルトでは「ですわ」や「くださいまし」のように
+	// すでにお嬢様言葉になっている箇所はそのまま出力するため、
+	// 変換済みの文章を再度変換しても、ほとんどの場合は結果が変わらない。
+	// 変換の前後で形態素解析の結果が変わる場合は、更に変換されることがある。
+	DisableKeepOjosamaStyle bool
+
+	// 名詞の前に「お」を付与する機能をOFFにする。
+	DisableHonorificPrefix bool
+
+	// 感嘆符や疑問符の前に波線や感嘆符を付与する機能をOFFにする。
+	DisableLongNote bool
+
+	// 「俺」を「私」にするような人称代名詞の変換をOFFにする。
+	DisablePronoun bool
+
+	// 「これ」を「こちら」にするようなこそあど言葉の変換をOFFにする。
+	DisableDemonstrative bool
+
+	// 「です」を「ですわ」にするような文末表現の変換をOFFにする。
+	DisableSentenceEnding bool
+
+	// 「汚い」を「きったねぇ」にするような下品な言葉への変換をOFFにする。
+	DisableVulgar bool
+
+	// 語尾の言い回しの候補が複数ある場合でも、乱数を使わずに常に最初の候補を使う。
+	// 例えば「野球しようぜ」は常に「お野球をいたしませんこと」に変換する。
+	DisableEndingVariation bool
+
+	// 文末の語尾を言い換えて、同じ語尾が続かないようにする設定。
+	// nil の場合は言い換えない。
+	EndingVariety *EndingVarietyConfig
+
+	// 無効にする変換ルールのID。
+	// 組み込みの変換ルールのIDは BuiltinRules で確認できる。
+	// 存在しないIDを指定した場合はエラーになる。
+	// 同じIDの変換ルールが複数ある場合は、すべて無効にする。
+	DisableRules []string
+
+	// 無効にする変換ルールの分類。
+	DisableCategories []Category
+
+	// 組み込みの変換ルールよりも優先して評価する独自の変換ルール。
+	PrependRules *RuleSet
+
+	// 組み込みの変換ルールにマッチしなかった時に評価する独自の変換ルール。
+	AppendRules *RuleSet
+
+	// 組み込みの変換ルールを使わない。
+	// PrependRules と AppendRules で組み込みの変換ルールを置き換える時に使う。
+	// すでにお嬢様言葉になっている箇所をそのまま出力する処理は無効にならない。
+	DisableBuiltinRules bool
+
+	// 形態素解析に使うユーザ辞書。
+	// 固有名詞を1つの単語として扱いたい場合に指定する。
+	// ユーザ辞書の単語の品詞は組み込みの変換ルールにマッチしないため、そのまま出力する。
+	// ユーザ辞書の単語の Features は {品詞, 分割した単語, 読み} になる。
+	// ユーザ辞書の作り方は kagome の dict.NewUserDict を参照。
+	UserDict *dict.UserDict
+
+	// 波線の後ろや句点の代わりに付与する感嘆符・疑問符のスタイル。
+	// デフォルトは MarkStyleRandom で、全角、半角、絵文字からランダムに選択する。
+	MarkStyle MarkStyle
+
+	// 感嘆符・疑問符のスタイルごとの重み。
+	// MarkStyle が MarkStyleRandom の時だけ使い、重みに従ってスタイルを選択する。
+	// 重みは MarkStyleFullWidth などの具体的なスタイルにだけ指定できる。
+	MarkStyleWeights map[MarkStyle]int
+
+	// 感嘆符や疑問符の前に付与する波線や感嘆符の付け方。
+	// nil の場合は変換の強さに応じた設定を使う。
+	// 指定した場合は、変換の強さで波線や感嘆符を付与しない場合でも付与する。
+	// 変換結果を固定したい場合は FixedLongNote を使う。
+	LongNote *LongNoteConfig
+
+	// 句点を変換する時の候補と重み。
+	// nil の場合は変換の強さに応じた候補を使う。
+	// 候補の並び順も乱数による選択結果に影響する。
+	// 既定の候補は DefaultKutenWeights で取得できる。
+	KutenWeights []KutenWeight
+
+	// 変換の強さ。
+	// デフォルトは LevelStandard 。
+	Level Level
+
+	// 乱数のシード値。
+	// 設定した場合は同じ入力と同じシード値に対して、常に同じ変換結果を返す。
+	// nil の場合は変換のたびにランダムなシード値を使う。
+	Seed *int64
+
+	// 変換元のテキストの最大の大きさ（バイト単位）。
+	// 超えた場合は変換せずに InputTooLargeError を返す。
+	// 逐次変換の場合は、読み込んだテキストの合計の大きさで判定する。
+	// 0 の場合は制限しない。
+	MaxInputSize int
+
+	forceCharsTestMode      *chars.TestMode // 単体テスト用のパラメータ
+	forceKutenToExclamation bool            // KutenToExclamationで強制的に3番目の要素を選択する
 }
 
 const (
@@ -77,8 +161,6 @@ const (
 
 var (
 	alnumRegexp = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
-
-	shuffleElementsKutenToExclamation = []string{"。", "。", "！", "❗"}
 )
 
 // Convert はテキストを壱百満天原サロメお嬢様風の口調に変換して返却する。
@@ -89,63 +171,151 @@ var (
 // opt は挙動を微調整するためのオプショナルなパラメータ。
 // 不要であれば nil を渡せば良い。
 //
-// 一部変換の途中でランダムに要素を選択するため、
-// 呼び出し側で乱数のシードの初期化を行うこと。
+// 形態素解析器はパッケージ内で共有するものを初回呼び出し時に生成して使い回す。
+// ただし opt で UserDict を指定した場合は、呼び出しごとに形態素解析器を生成する。
+// また PrependRules、AppendRules、DisableBuiltinRules を指定した場合は、
+// 呼び出しごとに変換ルールの索引を生成する。
+// 同じ設定で何度も変換する場合は NewConverter で生成した Converter を使う。
+//
+// 一部変換の途中でランダムに要素を選択する。
+// 変換結果を固定したい場合は opt の Seed を設定すること。
+//
+// src に UTF-8 として不正なバイト列や NUL 文字が含まれている場合は、
+// 位置を含めた InvalidInputError を返す。
 func Convert(src string, opt *ConvertOption) (string, error) {
-	t, err := tokenizer.New(ipa.Dict(), tokenizer.OmitBosEos())
+	c, err := converterFor(opt)
 	if err != nil {
 		return "", err
 	}
+	if err := c.validateDisableRules(opt); err != nil {
+		return "", err
+	}
+	return c.convert(context.Background(), src, opt)
+}
 
-	// tokenize
-	tokens := t.Tokenize(src)
+// convert は opt の設定でテキストをお嬢様言葉に変換する。
+func (c *Converter) convert(ctx context.Context, src string, opt *ConvertOption) (string, error) {
+	_, spans, err := c.convertSpans(ctx, src, opt)
+	if err != nil {
+		return "", err
+	}
 	var result strings.Builder
+	for _, sp := range spans {
+		result.WriteString(sp.String())
+	}
+	return result.String(), nil
+}
+
+// convertSpans は src をトークンに分割し、変換結果を区間ごとに返す。
+//
+// 変換結果の区間は変換元のトークンの順に並んでおり、
+// すべての区間の変換結果を連結すると変換後のテキストになる。
+//
+// src が opt の MaxInputSize を超える場合は InputTooLargeError を、
+// 変換できない文字を含む場合は InvalidInputError を返し、
+// 変換の途中で ctx がキャンセルされた場合は ctx のエラーを返す。
+func (c *Converter) convertSpans(ctx context.Context, src string, opt *ConvertOption) ([]tokenizer.Token, []span, error) {
+	if err := checkInputSize(opt, len(src)); err != nil {
+		return nil, nil, err
+	}
+	if err := validateInput(src, 0); err != nil {
+		return nil, nil, err
+	}
+	return c.convertSpansWithState(ctx, src, opt, newConvertState(opt))
+}
+
+// convertSpansWithState は st の状態を引き継いで src を変換し、変換結果を区間ごとに返す。
+//
+// 長い文章を分割して変換する場合に、分割した前後で乱数や直近の語尾を引き継ぐために使う。
+func (c *Converter) convertSpansWithState(ctx context.Context, src string, opt *ConvertOption, st *convertState) ([]tokenizer.Token, []span, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// tokenize
+	tokens := c.tokenizer.Tokenize(src)
+	datas := newTokenDatas(tokens)
+	rnd := st.rnd
+	var spans []span
 	var nounKeep bool
-	for i := 0; i < len(tokens); i++ {
-		token := tokens[i]
-		data := tokenizer.NewTokenData(token)
+	checker := newCancelChecker(ctx)
+	for i := 0; i < len(datas); i++ {
+		if err := checker.check(); err != nil {
+			return nil, nil, err
+		}
+
+		data := datas[i]
 		buf := data.Surface
 
 		// 英数字のみの単語の場合は何もしない
 		if alnumRegexp.MatchString(buf) {
-			result.WriteString(buf)
+			spans = append(spans, newSpan(i, buf, RuleKindAlnum, -1))
 			continue
 		}
 
+		// すでにお嬢様言葉になっている場合は何もしない
+		if opt == nil || !opt.DisableKeepOjosamaStyle {
+			if sp, ok := c.keepOjosamaStyle(datas, i); ok {
+				i = sp.end - 1
+				spans = append(spans, sp)
+				continue
+			}
+		}
+
 		// 名詞＋動詞＋終助詞の組み合わせに対して変換する
-		if s, n, ok := convertSentenceEndingParticle(tokens, i); ok {
-			i = n
-			result.WriteString(s)
+		if sp, ok := c.convertSentenceEndingParticle(datas, i, opt, rnd); ok {
+			i = sp.end - 1
+			spans = append(spans, sp)
 			continue
 		}
 
 		// 連続する条件による変換を行う
-		if s, n, ok := convertContinuousConditions(tokens, i, opt); ok {
-			i = n
-			result.WriteString(s)
+		if sp, ok := c.convertContinuousConditions(datas, i, opt, rnd); ok {
+			i = sp.end - 1
+			spans = append(spans, sp)
 			continue
 		}
 
 		// 特定条件は優先して無視する
-		if matchExcludeRule(data) {
-			result.WriteString(buf)
+		if n, ok := c.matchExcludeRule(data, opt); ok {
+			spans = append(spans, newSpan(i, buf, RuleKindExclude, n))
 			continue
 		}
 
 		// お嬢様言葉に変換
 		var kutenToEx bool
-		buf, nounKeep, i, kutenToEx = convert(data, tokens, i, buf, nounKeep, opt)
+		var sp span
+		sp, nounKeep, kutenToEx = c.convertToken(data, datas, i, nounKeep, opt, rnd)
+		i = sp.end - 1
 
 		if kutenToEx {
-			if ok, s, pos := randomKutenToExclamation(tokens, i, opt); ok {
-				buf += s
+			if ok, s, pos := randomKutenToExclamation(datas, i, opt, rnd); ok {
+				sp.kutenToExclamation = s
+				sp.end = pos + 1
 				i = pos
 			}
 		}
 
-		result.WriteString(buf)
+		spans = append(spans, sp)
 	}
-	return result.String(), nil
+
+	// 文書全体で同じ語尾が続かないように言い換える
+	if st.variety != nil {
+		c.varyEndings(datas, spans, st.variety, rnd)
+	}
+	return tokens, spans, nil
+}
+
+// newTokenDatas は形態素解析の結果を変換ルールの判定に使う形式に変換する。
+//
+// tokenizer.NewTokenData は呼び出すたびに品詞などを割り当て直すため、
+// 変換1回につき1度だけ変換して、すべての変換ルールの判定で使い回す。
+func newTokenDatas(tokens []tokenizer.Token) []tokenizer.TokenData {
+	datas := make([]tokenizer.TokenData, len(tokens))
+	for i, token := range tokens {
+		datas[i] = tokenizer.NewTokenData(token)
+	}
+	return datas
 }
 
 // convertSentenceEndingParticle は名詞＋動詞（＋助動詞）＋終助詞の組み合わせすべてを満たす場合に変換する。
@@ -159,11 +329,17 @@ func Convert(src string, opt *ConvertOption) (string, error) {
 // 例：お野球をいたしませんこと
 //
 // その他にも「野球するな」だと「お野球をしてはいけませんわ」になる。
-func convertSentenceEndingParticle(tokens []tokenizer.Token, tokenPos int) (string, int, bool) {
-	for _, r := range converter.SentenceEndingParticleConvertRules {
+//
+// 意味分類に該当する変換候補が複数ある場合は、ランダムに1つを選択する。
+func (c *Converter) convertSentenceEndingParticle(tokens []tokenizer.TokenData, tokenPos int, opt *ConvertOption, rnd *rand.Rand) (span, bool) {
+	for n, r := range c.sentenceEndingParticleConvertRules {
+		if !isRuleEnabled(opt, r.ID, r.Category) {
+			continue
+		}
+
 		var result strings.Builder
 		i := tokenPos
-		data := tokenizer.NewTokenData(tokens[i])
+		data := tokens[i]
 
 		// 先頭が一致するならば次の単語に進む
 		if !r.Conditions1.MatchAnyTokenData(data) {
@@ -174,12 +350,13 @@ func convertSentenceEndingParticle(tokens []tokenizer.Token, tokenPos int) (stri
 		}
 		s := data.Surface
 		// TODO: ベタ書きしててよくない
-		if tokendata.EqualsFeatures(data.Features, pos.NounsGeneral) || tokendata.EqualsFeatures(data.Features[:2], pos.NounsSaDynamic) {
+		if prefixModeOf(opt) != prefixModeNone &&
+			(tokendata.EqualsFeatures(data.Features, pos.NounsGeneral) || tokendata.HasPrefixFeatures(data.Features, pos.NounsSaDynamic)) {
 			s = "お" + s
 		}
 		result.WriteString(s)
 		i++
-		data = tokenizer.NewTokenData(tokens[i])
+		data = tokens[i]
 
 		// NOTE:
 		// 2つ目以降は value の値で置き換えるため
@@ -193,7 +370,7 @@ func convertSentenceEndingParticle(tokens []tokenizer.Token, tokenPos int) (stri
 			continue
 		}
 		i++
-		data = tokenizer.NewTokenData(tokens[i])
+		data = tokens[i]
 
 		// 助動詞があった場合は無視してトークンを進める。
 		// 別に無くても良い。
@@ -202,7 +379,7 @@ func convertSentenceEndingParticle(tokens []tokenizer.Token, tokenPos int) (stri
 				continue
 			}
 			i++
-			data = tokenizer.NewTokenData(tokens[i])
+			data = tokens[i]
 		}
 
 		// 最後、終助詞がどの意味分類に該当するかを取得
@@ -212,11 +389,21 @@ func convertSentenceEndingParticle(tokens []tokenizer.Token, tokenPos int) (stri
 		}
 
 		// 意味分類に該当する変換候補の文字列を返す
-		// TODO: 現状1個だけなので決め打ちで最初の1つ目を返す。
-		result.WriteString(r.Value[mt][0])
-		return result.String(), i, true
+		// 言い回しを固定する場合は乱数を使わずに最初の1つ目を返す
+		var r2 *rand.Rand
+		if opt == nil || !opt.DisableEndingVariation {
+			r2 = rnd
+		}
+		v, ok := r.SelectValue(mt, r2)
+		if !ok {
+			continue
+		}
+		result.WriteString(v)
+		sp := newSpan(tokenPos, result.String(), RuleKindSentenceEndingParticle, n)
+		sp.end = i + 1
+		return sp, true
 	}
-	return "", -1, false
+	return span{}, false
 }
 
 // convertContinuousConditions は連続する条件による変換ルールにマッチした変換結果を返す。
@@ -227,9 +414,13 @@ func convertSentenceEndingParticle(tokens []tokenizer.Token, tokenPos int) (stri
 // 連続する条件にマッチした場合は tokenPos をその分だけ進める必要があるため、進
 // めた後の tokenPos を返却する。
 //
-// 第三引数は変換ルールにマッチしたかどうかを返す。
-func convertContinuousConditions(tokens []tokenizer.Token, tokenPos int, opt *ConvertOption) (string, int, bool) {
-	for _, mc := range converter.ContinuousConditionsConvertRules {
+// 第二引数は変換ルールにマッチしたかどうかを返す。
+func (c *Converter) convertContinuousConditions(tokens []tokenizer.TokenData, tokenPos int, opt *ConvertOption, rnd *rand.Rand) (span, bool) {
+	for idx := range c.ruleCandidates(c.continuousRuleIndex, len(c.continuousConditionsConvertRules), tokens[tokenPos]) {
+		mc := c.continuousConditionsConvertRules[idx]
+		if !isRuleEnabled(opt, mc.ID, mc.Category) {
+			continue
+		}
 		if !matchContinuousConditions(tokens, tokenPos, mc.Conditions) {
 			continue
 		}
@@ -238,42 +429,64 @@ func convertContinuousConditions(tokens []tokenizer.Token, tokenPos int, opt *Co
 		result := mc.Value
 
 		// FIXME: 書き方が汚い
-		data := tokenizer.NewTokenData(tokens[tokenPos])
+		data := tokens[tokenPos]
 		surface := data.Surface
-		if appendablePrefix(data) {
+		if appendablePrefix(data, prefixModeOf(opt)) {
 			surface = "お" + surface
 		}
 		result = strings.ReplaceAll(result, "@1", surface)
+		sp := newSpan(tokenPos, result, RuleKindContinuousConditions, idx)
+		sp.end = n + 1
 
 		// 句点と～が同時に発生することは無いので早期リターンで良い
-		if ok, s, pos := randomKutenToExclamation(tokens, n, opt); ok {
-			result += s
-			n = pos
-			return result, n, true
+		if ok, s, pos := randomKutenToExclamation(tokens, n, opt, rnd); ok {
+			sp.kutenToExclamation = s
+			sp.end = pos + 1
+			return sp, true
 		}
 
 		if mc.AppendLongNote {
-			if note, pos := newLongNote(tokens, n, opt); note != "" {
-				result += note
-				n = pos
+			if note, pos := newLongNote(tokens, n, opt, rnd); note != "" {
+				sp.longNote = note
+				sp.end = pos + 1
 			}
 		}
 
-		return result, n, true
+		return sp, true
 	}
-	return "", -1, false
+	return span{}, false
+}
+
+// keepOjosamaStyle はすでにお嬢様言葉になっている連続するトークンを、
+// 変換せずにそのまま返す。
+func (c *Converter) keepOjosamaStyle(tokens []tokenizer.TokenData, tokenPos int) (span, bool) {
+	for idx, conds := range c.ojosamaStyleRules {
+		if !matchContinuousConditions(tokens, tokenPos, conds) {
+			continue
+		}
+
+		end := tokenPos + len(conds)
+		var result strings.Builder
+		for _, token := range tokens[tokenPos:end] {
+			result.WriteString(token.Surface)
+		}
+		sp := newSpan(tokenPos, result.String(), RuleKindOjosamaStyle, idx)
+		sp.end = end
+		return sp, true
+	}
+	return span{}, false
 }
 
 // matchContinuousConditions は tokens の tokenPos の位置からのトークンが、連続する条件にすべてマッチするかを判定する。
 //
 // 次のトークンが存在しなかったり、1つでも条件が不一致になった場合 false を返す。
-func matchContinuousConditions(tokens []tokenizer.Token, tokenPos int, ccs converter.ConvertConditions) bool {
+func matchContinuousConditions(tokens []tokenizer.TokenData, tokenPos int, ccs converter.ConvertConditions) bool {
 	j := tokenPos
 	for _, conds := range ccs {
 		if len(tokens) <= j {
 			return false
 		}
-		data := tokenizer.NewTokenData(tokens[j])
+		data := tokens[j]
 		if !conds.EqualsTokenData(data) {
 			return false
 		}
@@ -283,91 +496,112 @@ func matchContinuousConditions(tokens []tokenizer.Token, tokenPos int, ccs conve
 }
 
 // matchExcludeRule は除外ルールと一致するものが存在するかを判定する。
-func matchExcludeRule(data tokenizer.TokenData) bool {
+//
+// 一致した場合は一致した除外ルールの位置を返す。
+func (c *Converter) matchExcludeRule(data tokenizer.TokenData, opt *ConvertOption) (int, bool) {
 excludeLoop:
-	for _, c := range converter.ExcludeRules {
-		if !c.Conditions.MatchAllTokenData(data) {
+	for i := range c.ruleCandidates(c.excludeRuleIndex, len(c.excludeRules), data) {
+		r := c.excludeRules[i]
+		if !isRuleEnabled(opt, r.ID, r.Category) {
 			continue excludeLoop
 		}
-		return true
+		if !r.Conditions.MatchAllTokenData(data) {
+			continue excludeLoop
+		}
+		return i, true
 	}
-	return false
+	return -1, false
 }
 
-// convert は基本的な変換を行う。
-func convert(data tokenizer.TokenData, tokens []tokenizer.Token, i int, surface string, nounKeep bool, opt *ConvertOption) (string, bool, int, bool) {
-	var ok bool
-	var c converter.ConvertRule
-	if ok, c = matchConvertRule(data, tokens, i); !ok {
-		result := surface
-		result, nounKeep = appendPrefix(data, tokens, i, result, nounKeep)
-		return result, nounKeep, i, false
+// convertToken は基本的な変換を行う。
+func (c *Converter) convertToken(data tokenizer.TokenData, tokens []tokenizer.TokenData, i int, nounKeep bool, opt *ConvertOption, rnd *rand.Rand) (span, bool, bool) {
+	n, ok := c.matchConvertRule(data, tokens, i, opt)
+	if !ok {
+		sp := newSpan(i, data.Surface, RuleKindNone, -1)
+		sp.prefix, nounKeep = honorificPrefix(data, tokens, i, nounKeep, opt)
+		return sp, nounKeep, false
 	}
 
-	result := c.Value
-	pos := i
-	result = strings.ReplaceAll(result, "@1", data.Surface)
+	r := c.convertRules[n]
+	sp := newSpan(i, strings.ReplaceAll(r.Value, "@1", data.Surface), RuleKindConvert, n)
 
 	// 波線伸ばしをランダムに追加する
-	if c.AppendLongNote {
-		if note, pos2 := newLongNote(tokens, i, opt); note != "" {
-			result += note
-			pos = pos2
+	if r.AppendLongNote {
+		if note, pos := newLongNote(tokens, i, opt, rnd); note != "" {
+			sp.longNote = note
+			sp.end = pos + 1
 		}
 	}
 
 	// 手前に「お」を付ける
-	if !c.DisablePrefix {
-		result, nounKeep = appendPrefix(data, tokens, i, result, nounKeep)
+	if !r.DisablePrefix {
+		sp.prefix, nounKeep = honorificPrefix(data, tokens, i, nounKeep, opt)
 	}
 
-	return result, nounKeep, pos, c.EnableKutenToExclamation
+	return sp, nounKeep, r.EnableKutenToExclamation
 }
 
-func matchConvertRule(data tokenizer.TokenData, tokens []tokenizer.Token, i int) (bool, converter.ConvertRule) {
+// matchConvertRule は data にマッチする変換ルールの位置を返す。
+func (c *Converter) matchConvertRule(data tokenizer.TokenData, tokens []tokenizer.TokenData, i int, opt *ConvertOption) (int, bool) {
 	var beforeToken tokenizer.TokenData
 	var beforeTokenOK bool
 	if 0 < i {
-		beforeToken = tokenizer.NewTokenData(tokens[i-1])
+		beforeToken = tokens[i-1]
 		beforeTokenOK = true
 	}
 
 	var afterToken tokenizer.TokenData
 	var afterTokenOK bool
 	if i+1 < len(tokens) {
-		afterToken = tokenizer.NewTokenData(tokens[i+1])
+		afterToken = tokens[i+1]
 		afterTokenOK = true
 	}
 
-	for _, c := range converter.ConvertRules {
-		if !c.Conditions.MatchAllTokenData(data) {
+	for n := range c.ruleCandidates(c.convertRuleIndex, len(c.convertRules), data) {
+		r := c.convertRules[n]
+		if !isRuleEnabled(opt, r.ID, r.Category) {
+			continue
+		}
+		if !r.Conditions.MatchAllTokenData(data) {
 			continue
 		}
 
 		// 前に続く単語をみて変換を無視する
-		if beforeTokenOK && c.BeforeIgnoreConditions.MatchAnyTokenData(beforeToken) {
+		if beforeTokenOK && r.BeforeIgnoreConditions.MatchAnyTokenData(beforeToken) {
 			break
 		}
 
 		// 次に続く単語をみて変換を無視する
-		if afterTokenOK && c.AfterIgnoreConditions.MatchAnyTokenData(afterToken) {
+		if afterTokenOK && r.AfterIgnoreConditions.MatchAnyTokenData(afterToken) {
 			break
 		}
 
 		// 文の区切りか、文の終わりの時だけ有効にする。
 		// 次のトークンが存在して、且つ次のトークンが文を区切るトークンでない時
 		// は変換しない。
-		if c.EnableWhenSentenceSeparation && afterTokenOK && !isSentenceSeparation(afterToken) {
+		if r.EnableWhenSentenceSeparation && afterTokenOK && !isSentenceSeparation(afterToken) {
 			break
 		}
 
-		return true, c
+		return n, true
 	}
-	return false, converter.ConvertRule{}
+	return -1, false
 }
 
-func appendablePrefix(data tokenizer.TokenData) bool {
-	if !tokendata.EqualsFeatures(data.Features, []string{"名詞", "一般"}) && !tokendata.EqualsFeatures(data.Features[:2], []string{"名詞", "固有名詞"}) {
+// appendablePrefix は data に「お」を付与できるかを判定する。
+func appendablePrefix(data tokenizer.TokenData, mode prefixMode) bool {
+	switch mode {
+	case prefixModeNone:
+		return false
+	case prefixModeAggressive:
+		// サ変接続の名詞にも付与する。
+		// 例: お勉強
+		if tokendata.EqualsFeatures(data.Features, pos.NounsSaDynamic) {
+			return !tokendata.IsPoliteWord(data)
+		}
+	}
+
+	if !tokendata.EqualsFeatures(data.Features, []string{"名詞", "一般"}) && !tokendata.HasPrefixFeatures(data.Features, []string{"名詞", "固有名詞"}) {
 		return false
 	}
 
@@ -379,42 +613,48 @@ func appendablePrefix(data tokenizer.TokenData) bool {
 	return true
 }
 
-// appendPrefix は surface の前に「お」を付ける。
-func appendPrefix(data tokenizer.TokenData, tokens []tokenizer.Token, i int, surface string, nounKeep bool) (string, bool) {
-	if !appendablePrefix(data) {
-		return surface, false
+// honorificPrefix は data の前に付与する「お」を返す。
+//
+// 「お」を付与しない場合は空文字を返す。
+func honorificPrefix(data tokenizer.TokenData, tokens []tokenizer.TokenData, i int, nounKeep bool, opt *ConvertOption) (string, bool) {
+	mode := prefixModeOf(opt)
+	if !appendablePrefix(data, mode) {
+		return "", false
 	}
 
 	// 次のトークンが動詞の場合は「お」を付けない。
 	// 例: プレイする
-	if i+1 < len(tokens) {
-		data := tokenizer.NewTokenData(tokens[i+1])
+	if i+1 < len(tokens) && mode != prefixModeAggressive {
+		data := tokens[i+1]
 		if tokendata.EqualsFeatures(data.Features, []string{"動詞", "自立"}) {
-			return surface, nounKeep
+			return "", nounKeep
 		}
 	}
 
 	// すでに「お」を付与されているので、「お」を付与しない
 	if nounKeep {
-		return surface, false
+		return "", false
 	}
 
 	if 0 < i {
-		data := tokenizer.NewTokenData(tokens[i-1])
+		data := tokens[i-1]
 
 		// 手前のトークンが「お」の場合は付与しない
 		if tokendata.EqualsFeatures(data.Features, []string{"接頭詞", "名詞接続"}) {
-			return surface, false
+			// 「お」が付与されている名詞の後に名詞が続く場合は、
+			// その名詞にも「お」を付与しない。
+			// 例: お頭イカれてる
+			return "", data.Surface == "お"
 		}
 
 		// サ変接続が来ても付与しない。
 		// 例: 横断歩道、解体新書
 		if tokendata.EqualsFeatures(data.Features, []string{"名詞", "サ変接続"}) {
-			return surface, false
+			return "", false
 		}
 	}
 
-	return "お" + surface, true
+	return "お", true
 }
 
 // isSentenceSeparation は data が文の区切りに使われる token かどうかを判定する。
@@ -426,37 +666,35 @@ func isSentenceSeparation(data tokenizer.TokenData) bool {
 // newLongNote は次の token が感嘆符か疑問符の場合に波線、感嘆符、疑問符をランダムに生成する。
 //
 // 乱数が絡むと単体テストがやりづらくなるので、 opt を使うことで任意の数付与できるようにしている。
-func newLongNote(tokens []tokenizer.Token, i int, opt *ConvertOption) (string, int) {
+func newLongNote(tokens []tokenizer.TokenData, i int, opt *ConvertOption, rnd *rand.Rand) (string, int) {
+	if !isLongNoteEnabled(opt) {
+		return "", -1
+	}
+
 	var ok bool
 	var s string
 	if ok, s = creatableLongNote(tokens, i); !ok {
 		return "", -1
 	}
 
-	var tm *chars.TestMode
-	if opt != nil {
-		tm = opt.forceCharsTestMode
-	}
+	cfg := longNoteConfigOf(opt)
+	w := cfg.WavyLineCount.sample(rnd)
+	e := cfg.MarkCount.sample(rnd)
 
-	var (
-		w, e int
-	)
-	if opt != nil && opt.forceAppendLongNote.enable {
-		// opt がある場合に限って任意の数付与できる。基本的に単体テスト用途。
-		w = opt.forceAppendLongNote.wavyLineCount
-		e = opt.forceAppendLongNote.exclamationMarkCount
-	} else {
-		w = rand.Intn(3)
-		e = rand.Intn(3)
+	// 入力で感嘆符や疑問符が連続しているほど強調する
+	if cfg.ScaleWithInput {
+		extra := countContinuousMarks(tokens, i) - 1
+		w += extra
+		e += extra
 	}
 
 	var suffix strings.Builder
 	for i := 0; i < w; i++ {
-		suffix.WriteString("～")
+		suffix.WriteString(cfg.WavyLine)
 	}
 
-	// ！or？をどれかからランダムに選択する
-	feq := chars.SampleExclamationQuestionByValue(s, tm)
+	// ！or？を opt の設定のスタイルから選択する
+	feq := longNoteMark(s, opt, rnd)
 
 	// 次の token は必ず感嘆符か疑問符のどちらかであることが確定しているため
 	// -1 して数を調整している。
@@ -466,17 +704,19 @@ func newLongNote(tokens []tokenizer.Token, i int, opt *ConvertOption) (string, i
 
 	// 後ろに！や？が連続する場合、それらをすべて feq と同じ種類（半角、全角、
 	// 絵文字）の！や？に置き換えて返却する。
-	excl, pos := getContinuousExclamationMark(tokens, i, feq)
+	// 入力のスタイルに合わせる場合は置き換えない。
+	keepStyle := opt != nil && opt.MarkStyle == MarkStyleInput
+	excl, pos := getContinuousExclamationMark(tokens, i, feq, keepStyle)
 	suffix.WriteString(excl)
 	return suffix.String(), pos
 }
 
-func creatableLongNote(tokens []tokenizer.Token, i int) (bool, string) {
+func creatableLongNote(tokens []tokenizer.TokenData, i int) (bool, string) {
 	if len(tokens) <= i+1 {
 		return false, ""
 	}
 
-	data := tokenizer.NewTokenData(tokens[i+1])
+	data := tokens[i+1]
 	for _, s := range []string{"！", "？", "!", "?"} {
 		if data.Surface != s {
 			continue
@@ -486,21 +726,21 @@ func creatableLongNote(tokens []tokenizer.Token, i int) (bool, string) {
 	return false, ""
 }
 
-func getContinuousExclamationMark(tokens []tokenizer.Token, i int, feq *chars.ExclamationQuestionMark) (string, int) {
+func getContinuousExclamationMark(tokens []tokenizer.TokenData, i int, feq *chars.ExclamationQuestionMark, keepStyle bool) (string, int) {
 	var result strings.Builder
 	pos := i
 
 	for j := i + 1; j < len(tokens); j++ {
-		token := tokens[j]
-		data := tokenizer.NewTokenData(token)
+		data := tokens[j]
 		for _, r := range data.Surface {
 			surface := string(r)
 			if ok, eq := chars.IsExclamationQuestionMark(surface); !ok {
 				return result.String(), pos
+			} else if keepStyle {
+				result.WriteString(eq.Value)
 			} else {
 				// e は！か？のどちらかなので、同じスタイルの文字を取得して追加
-				if got := chars.FindExclamationQuestionByStyleAndMeaning(feq.Style, eq.Meaning); got != nil {
-
+				if got := chars.FindExclamationQuestionByStyleAndMeaningWithFallback(feq.Style, eq.Meaning); got != nil {
 					result.WriteString(got.Value)
 				}
 			}
@@ -513,7 +753,7 @@ func getContinuousExclamationMark(tokens []tokenizer.Token, i int, feq *chars.Ex
 }
 
 // randomKutenToExclamation はランダムで句点を！に変換する。
-func randomKutenToExclamation(tokens []tokenizer.Token, tokenPos int, opt *ConvertOption) (bool, string, int) {
+func randomKutenToExclamation(tokens []tokenizer.TokenData, tokenPos int, opt *ConvertOption, rnd *rand.Rand) (bool, string, int) {
 	if opt != nil && opt.DisableKutenToExclamation {
 		return false, "", tokenPos
 	}
@@ -523,19 +763,46 @@ func randomKutenToExclamation(tokens []tokenizer.Token, tokenPos int, opt *Conve
 		return false, "", tokenPos
 	}
 
-	data := tokenizer.NewTokenData(tokens[pos])
+	data := tokens[pos]
 	if !tokendata.IsKuten(data) {
 		return false, "", tokenPos
 	}
 
-	// テスト用に値をすげ替えられるようにする
-	var s []string
-	if opt != nil && opt.forceKutenToExclamation {
-		s = []string{"❗", "❗"}
-	} else {
-		s = shuffleElementsKutenToExclamation
+	v, ok := sampleKuten(kutenWeightsOf(opt), rnd)
+	if !ok {
+		return false, "", tokenPos
 	}
+	return true, kutenMark(v, tokens, opt, rnd), pos
+}
+
+// convertState は変換の間で引き継ぐ状態。
+//
+// 通常は変換1回ごとに生成するが、長い文章を分割して変換する場合は
+// 分割したすべての変換で同じものを使う。
+type convertState struct {
+	rnd     *rand.Rand
+	variety *endingVariety // 語尾を言い換えない場合は nil
+}
 
-	rand.Shuffle(len(s), func(i, j int) { s[i], s[j] = s[j], s[i] })
-	return true, s[0], pos
+// newConvertState は opt の設定で変換を始める時の状態を返す。
+func newConvertState(opt *ConvertOption) *convertState {
+	st := &convertState{rnd: newRand(opt)}
+	if opt != nil && opt.EndingVariety != nil {
+		st.variety = newEndingVariety(opt.EndingVariety)
+	}
+	return st
+}
+
+// newRand は変換1回分で使う乱数生成器を返す。
+//
+// 乱数生成器はゴルーチン間で共有すると安全ではないため、変換のたびに生成する。
+// シード値が指定されていない場合は、グローバルな乱数からシード値を生成する。
+func newRand(opt *ConvertOption) *rand.Rand {
+	var seed int64
+	if opt != nil && opt.Seed != nil {
+		seed = *opt.Seed
+	} else {
+		seed = rand.Int63()
+	}
+	return rand.New(rand.NewSource(seed))
 }
@@ -1,17 +1,22 @@
 package ojosama
 
 import (
+	"errors"
 	"fmt"
-	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
-	"time"
+	"unicode/utf8"
 
+	"github.com/ikawaha/kagome-dict/dict"
+	"github.com/ikawaha/kagome/v2/tokenizer"
 	"github.com/jiro4989/ojosama/internal/chars"
 	"github.com/stretchr/testify/assert"
 )
 
 func ExampleConvert() {
-	rand.Seed(time.Now().UnixNano())
 	got, err := Convert("ハーブです", nil)
 
 	fmt.Println("got: " + got)
@@ -214,11 +219,7 @@ func TestConvert(t *testing.T) {
 			src:  "これはハーブです！",
 			want: "こちらはおハーブですわ～～！！！",
 			opt: &ConvertOption{
-				forceAppendLongNote: forceAppendLongNote{
-					enable:               true,
-					wavyLineCount:        2,
-					exclamationMarkCount: 3,
-				},
+				LongNote: FixedLongNote(2, 3),
 				forceCharsTestMode: &chars.TestMode{
 					Pos: 0,
 				},
@@ -230,11 +231,7 @@ func TestConvert(t *testing.T) {
 			src:  "これはハーブです!これもハーブです?",
 			want: "こちらはおハーブですわ～～!!!こちらもおハーブですわ～～???",
 			opt: &ConvertOption{
-				forceAppendLongNote: forceAppendLongNote{
-					enable:               true,
-					wavyLineCount:        2,
-					exclamationMarkCount: 3,
-				},
+				LongNote: FixedLongNote(2, 3),
 				forceCharsTestMode: &chars.TestMode{
 					Pos: 1,
 				},
@@ -246,11 +243,7 @@ func TestConvert(t *testing.T) {
 			src:  "プレイします！",
 			want: "プレイいたしますわ～～！！！",
 			opt: &ConvertOption{
-				forceAppendLongNote: forceAppendLongNote{
-					enable:               true,
-					wavyLineCount:        2,
-					exclamationMarkCount: 3,
-				},
+				LongNote: FixedLongNote(2, 3),
 				forceCharsTestMode: &chars.TestMode{
 					Pos: 0,
 				},
@@ -262,11 +255,7 @@ func TestConvert(t *testing.T) {
 			src:  "プレイする！",
 			want: "プレイいたしますわ～～！！！",
 			opt: &ConvertOption{
-				forceAppendLongNote: forceAppendLongNote{
-					enable:               true,
-					wavyLineCount:        2,
-					exclamationMarkCount: 3,
-				},
+				LongNote: FixedLongNote(2, 3),
 				forceCharsTestMode: &chars.TestMode{
 					Pos: 0,
 				},
@@ -287,11 +276,7 @@ func TestConvert(t *testing.T) {
 			src:  "ハーブがありました！",
 			want: "おハーブがありましたわ～～！！！",
 			opt: &ConvertOption{
-				forceAppendLongNote: forceAppendLongNote{
-					enable:               true,
-					wavyLineCount:        2,
-					exclamationMarkCount: 3,
-				},
+				LongNote: FixedLongNote(2, 3),
 				forceCharsTestMode: &chars.TestMode{
 					Pos: 0,
 				},
@@ -426,28 +411,28 @@ func TestConvert(t *testing.T) {
 		{
 			desc:    "正常系: こそあど言葉（こ）にも対応しておりましてよ",
 			src:     "これ、この、ここ、こちら、こう、こんな。",
-			want:    "こちら、こちらの、こちら、こちら、こう、このような。",
+			want:    "こちら、こちらの、こちら、こちら、こう、こちらのような。",
 			opt:     opt,
 			wantErr: false,
 		},
 		{
 			desc:    "正常系: こそあど言葉（そ）にも対応しておりましてよ",
 			src:     "それ、その、そこ、そちら、そう、そんな。",
-			want:    "そちら、そちらの、そちら、そちら、そう、そのような。",
+			want:    "そちら、そちらの、そちら、そちら、そう、そちらのような。",
 			opt:     opt,
 			wantErr: false,
 		},
 		{
 			desc:    "正常系: こそあど言葉（あ）にも対応しておりましてよ",
 			src:     "あれは、あの、あそこ、あちら、ああ、あんな。",
-			want:    "あちらは、あちらの、あちら、あちら、ああ、あのような。",
+			want:    "あちらは、あちらの、あちら、あちら、ああ、あちらのような。",
 			opt:     opt,
 			wantErr: false,
 		},
 		{
 			desc:    "正常系: こそあど言葉（ど）にも対応しておりましてよ",
 			src:     "どれ、どの、どこ、どちら、どう、どんな。",
-			want:    "どちら、どちらの、どちら、どちら、どう、どのような。",
+			want:    "どちら、どちらの、どちら、どちら、どう、どちらのような。",
 			opt:     opt,
 			wantErr: false,
 		},
@@ -476,7 +461,7 @@ func TestConvert(t *testing.T) {
 			desc:    "正常系: 名詞＋動詞＋終助詞の組み合わせも変換いたしますわ～～！！この処理すっごく大変でしたの！！",
 			src:     "野球しようぜ。サッカーやろうよ。バスケやるか。柔道やるな。陸上すんな。テニスするぞ。卓球やるべ。ゲームするの。",
 			want:    "お野球をいたしませんこと。おサッカーをいたしませんこと。おバスケをいたしますわ。お柔道をしてはいけませんわ。お陸上をしてはいけませんわ。おテニスをいたしますわよ。お卓球をいたしませんこと。おゲームをいたしますわよ。",
-			opt:     opt,
+			opt:     &ConvertOption{DisableKutenToExclamation: true, DisableEndingVariation: true},
 			wantErr: false,
 		},
 		{
@@ -505,11 +490,7 @@ func TestConvert(t *testing.T) {
 			src:  "です！？!?❗❓",
 			want: "ですわ～！？！？！？",
 			opt: &ConvertOption{
-				forceAppendLongNote: forceAppendLongNote{
-					enable:               true,
-					wavyLineCount:        1,
-					exclamationMarkCount: 1,
-				},
+				LongNote: FixedLongNote(1, 1),
 				forceCharsTestMode: &chars.TestMode{
 					Pos: 0,
 				},
@@ -521,11 +502,7 @@ func TestConvert(t *testing.T) {
 			src:  "です！？!?❗❓",
 			want: "ですわ～❗❓❗❓❗❓",
 			opt: &ConvertOption{
-				forceAppendLongNote: forceAppendLongNote{
-					enable:               true,
-					wavyLineCount:        1,
-					exclamationMarkCount: 1,
-				},
+				LongNote: FixedLongNote(1, 1),
 				forceCharsTestMode: &chars.TestMode{
 					Pos: 2,
 				},
@@ -537,11 +514,7 @@ func TestConvert(t *testing.T) {
 			src:  "です！寿司",
 			want: "ですわ～～❗❗❗お寿司",
 			opt: &ConvertOption{
-				forceAppendLongNote: forceAppendLongNote{
-					enable:               true,
-					wavyLineCount:        2,
-					exclamationMarkCount: 3,
-				},
+				LongNote: FixedLongNote(2, 3),
 				forceCharsTestMode: &chars.TestMode{
 					Pos: 2,
 				},
@@ -596,6 +569,41 @@ func TestConvert(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			desc:    "正常系: 「ます」の後ろに終助詞が続く場合も「ますわ」に変換いたしますわね",
+			src:     "それでは始めますね",
+			want:    "それでは始めますわね",
+			opt:     opt,
+			wantErr: false,
+		},
+		{
+			desc:    "正常系: 「ますよ」は「ますわよ」に変換いたしますわよ",
+			src:     "行きますよ",
+			want:    "行きますわよ",
+			opt:     opt,
+			wantErr: false,
+		},
+		{
+			desc:    "正常系: 「それだけ」の「それ」も「そちら」に変換いたしますわ",
+			src:     "わたしはそれだけではありません",
+			want:    "わたくしはそちらだけではありません",
+			opt:     opt,
+			wantErr: false,
+		},
+		{
+			desc:    "正常系: 副詞の「それだけ」も「そちらだけ」に変換いたしますわ",
+			src:     "それだけじゃない",
+			want:    "そちらだけではありません",
+			opt:     opt,
+			wantErr: false,
+		},
+		{
+			desc:    "正常系: 「た」に続く終助詞の「さ」は「わ」に変換いたしますわ",
+			src:     "見てなかったさ。",
+			want:    "見てなかったわ。",
+			opt:     opt,
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -614,3 +622,371 @@ func TestConvert(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertSeed(t *testing.T) {
+	assert := assert.New(t)
+
+	src := "ハーブです！ハーブです。ハーブです？これはハーブです！！。"
+	seed := int64(42)
+	opt := &ConvertOption{Seed: &seed}
+
+	want, err := Convert(src, opt)
+	assert.NoError(err)
+
+	// 同じシード値であれば何度変換しても同じ結果になりますわ
+	for i := 0; i < 20; i++ {
+		got, err := Convert(src, opt)
+		assert.NoError(err)
+		assert.Equal(want, got)
+	}
+
+	// 複数のゴルーチンから同じシード値で同時に変換しても同じ結果になりますわ。
+	// 乱数生成器は呼び出しごとに生成するため、go test -race でもデータ競合になりませんわ
+	c, err := NewConverter(opt)
+	assert.NoError(err)
+	const n = 16
+	var wg sync.WaitGroup
+	results := make([]string, n*2)
+	errs := make([]error, n*2)
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = Convert(src, opt)
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			results[n+i], errs[n+i] = c.Convert(src)
+		}(i)
+	}
+	wg.Wait()
+	for i := range results {
+		assert.NoError(errs[i])
+		assert.Equal(want, results[i])
+	}
+}
+
+func TestConvertEndingVariation(t *testing.T) {
+	// 組み込みの変換ルールより先に評価させるため、同じ条件で重みだけ違うルールを作る
+	weightedRule := func(weights []int) *RuleSet {
+		return &RuleSet{
+			SentenceEndingRules: []SentenceEndingRule{
+				{
+					Conditions1:            []Condition{{Features: []string{"名詞", "一般"}}},
+					Conditions2:            []Condition{{BaseForm: "する"}},
+					AuxiliaryVerb:          []Condition{{Features: []string{"助動詞"}, Surface: "う"}},
+					SentenceEndingParticle: map[MeaningType][]Condition{MeaningTypeHope: {{Surface: "ぜ"}}},
+					Value:                  map[MeaningType][]string{MeaningTypeHope: {"をいたしませんこと", "をいたしましょう"}},
+					ValueWeights:           map[MeaningType][]int{MeaningTypeHope: weights},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		desc string
+		opt  ConvertOption
+		want []string
+	}{
+		{
+			desc: "正常系: 変換候補が複数ある場合はランダムにいずれかを選択いたしますわ",
+			opt:  ConvertOption{},
+			want: []string{"お野球をいたしませんこと", "お野球をいたしましょう"},
+		},
+		{
+			desc: "正常系: 言い回しを固定した場合は常に最初の変換候補を使いますわ",
+			opt:  ConvertOption{DisableEndingVariation: true},
+			want: []string{"お野球をいたしませんこと"},
+		},
+		{
+			desc: "正常系: 重みが0の変換候補は選択いたしませんわ",
+			opt:  ConvertOption{PrependRules: weightedRule([]int{0, 1})},
+			want: []string{"お野球をいたしましょう"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got := make(map[string]struct{})
+			for i := int64(0); i < 30; i++ {
+				seed := i
+				opt := tt.opt
+				opt.Seed = &seed
+				s, err := Convert("野球しようぜ", &opt)
+				assert.NoError(err)
+				got[s] = struct{}{}
+			}
+
+			// すべての候補が選択され、それ以外の文字列にはなりませんわ
+			assert.Len(got, len(tt.want))
+			for _, w := range tt.want {
+				assert.Contains(got, w)
+			}
+		})
+	}
+}
+
+func TestConvertUserDict(t *testing.T) {
+	d, err := dict.NewUserDict("testdata/userdict/userdict.txt")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		desc string
+		src  string
+		opt  *ConvertOption
+		want string
+	}{
+		{
+			desc: "正常系: ユーザ辞書がない場合は一般名詞として「お」を付与しますわ",
+			src:  "ハーブティーを飲みます",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+			},
+			want: "おハーブティーを飲みますわ",
+		},
+		{
+			desc: "正常系: ユーザ辞書の単語は1つの単語としてそのまま出力いたしますわ",
+			src:  "ハーブティーを飲みます",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				UserDict:                  d,
+			},
+			want: "ハーブティーを飲みますわ",
+		},
+		{
+			desc: "正常系: ユーザ辞書の単語を変換ルールの条件に使えますわ",
+			src:  "壱百満天原サロメです",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				UserDict:                  d,
+				PrependRules: &RuleSet{
+					ConvertRules: []Rule{
+						{
+							Conditions: []Condition{{Surface: "壱百満天原サロメ"}},
+							Value:      "@1お嬢様",
+						},
+					},
+				},
+			},
+			want: "壱百満天原サロメお嬢様ですわ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := Convert(tt.src, tt.opt)
+			assert.NoError(err)
+			assert.Equal(tt.want, got)
+		})
+	}
+}
+
+func TestConvertKeepOjosamaStyle(t *testing.T) {
+	tests := []struct {
+		desc string
+		src  string
+		opt  *ConvertOption
+		want string
+	}{
+		{
+			desc: "正常系: すでにお嬢様言葉になっている場合はそのままにいたしますわ",
+			src:  "お願いいたしますわ",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+			},
+			want: "お願いいたしますわ",
+		},
+		{
+			desc: "正常系: すでにお嬢様言葉になっている場合はそのままにいたしますわ",
+			src:  "こちらはおハーブですわ。お使いくださいまし。皆様方",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+			},
+			want: "こちらはおハーブですわ。お使いくださいまし。皆様方",
+		},
+		{
+			desc: "正常系: 「お」が付与された名詞に続く名詞には「お」を付与いたしませんわ",
+			src:  "おニュース記事",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+			},
+			want: "おニュース記事",
+		},
+		{
+			desc: "正常系: オプションで無効にした場合は変換いたしますわ",
+			src:  "おハーブですわ",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				DisableKeepOjosamaStyle:   true,
+			},
+			want: "おハーブですわですわ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := Convert(tt.src, tt.opt)
+			assert.NoError(err)
+			assert.Equal(tt.want, got)
+		})
+	}
+}
+
+// TestConvertIdempotent は変換済みの文章をさらに変換しても、
+// 変換結果が変わらないことを testdata 配下のすべての文章と、
+// 変換前後で形態素解析の結果が変わりやすい文章で確認する。
+func TestConvertIdempotent(t *testing.T) {
+	// ランダムな装飾が付与されないようにする
+	opt := &ConvertOption{
+		DisableKutenToExclamation: true,
+		LongNote:                  FixedLongNote(0, 0),
+		forceCharsTestMode: &chars.TestMode{
+			Pos: 0,
+		},
+	}
+
+	type testCase struct {
+		desc string
+		src  string
+	}
+	tests := []testCase{
+		{desc: "正常系: 動詞として解析される「あれ」も変換し直しませんわ", src: "あれください"},
+		{desc: "正常系: 終助詞の「さ」の前の「た」も変換し直しませんわ", src: "見てなかったさ。"},
+		{desc: "正常系: 「そんな」を変換した「そちらのような」も変換し直しませんわ", src: "そんな話です"},
+		{desc: "正常系: 「このよう」も変換し直しませんわ", src: "このようにします"},
+		{desc: "正常系: 副詞の「それだけ」も変換し直しませんわ", src: "それだけじゃない"},
+		{desc: "正常系: 「くれますの」も変換し直しませんわ", src: "聞いてくれるか？"},
+		{desc: "正常系: 「てくれます」も変換し直しませんわ", src: "手伝ってくれます"},
+		{desc: "正常系: 下品な言葉も変換し直しませんわ", src: "臭いね"},
+	}
+
+	files, err := filepath.Glob("testdata/*.txt")
+	assert.NoError(t, err)
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		assert.NoError(t, err)
+		if !utf8.Valid(b) {
+			// SJISのファイルは対象外
+			continue
+		}
+
+		for i, line := range strings.Split(string(b), "\n") {
+			tests = append(tests, testCase{desc: fmt.Sprintf("%s:%d", f, i+1), src: line})
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			want, err := Convert(tt.src, opt)
+			assert.NoError(err)
+
+			got, err := Convert(want, opt)
+			assert.NoError(err)
+			assert.Equal(want, got)
+		})
+	}
+}
+
+func TestAppendablePrefix(t *testing.T) {
+	tests := []struct {
+		desc string
+		data tokenizer.TokenData
+		mode prefixMode
+		want bool
+	}{
+		{
+			desc: "正常系: 固有名詞には「お」を付与できますわ",
+			data: tokenizer.TokenData{Features: []string{"名詞", "固有名詞", "一般", "*"}, Reading: "ハーブ"},
+			mode: prefixModeStandard,
+			want: true,
+		},
+		{
+			desc: "正常系: 品詞が1つしかない単語でも判定できますわ",
+			data: tokenizer.TokenData{Features: []string{"カスタム人名"}[:1:1]},
+			mode: prefixModeAggressive,
+			want: false,
+		},
+		{
+			desc: "正常系: 品詞が無い単語でも判定できますわ",
+			data: tokenizer.TokenData{},
+			mode: prefixModeStandard,
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got := appendablePrefix(tt.data, tt.mode)
+			assert.Equal(tt.want, got)
+		})
+	}
+}
+
+// FuzzConvert は任意の入力で変換してもパニックせず、
+// 半角英数字をそのまま出力することを検証する。
+//
+//	go test -fuzz FuzzConvert
+func FuzzConvert(f *testing.F) {
+	files, err := filepath.Glob("testdata/*.txt")
+	assert.NoError(f, err)
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		assert.NoError(f, err)
+		// 不正な入力のエラーの確認のため SJIS のファイルもそのまま使う
+		f.Add(string(b))
+		for _, line := range strings.Split(string(b), "\n") {
+			f.Add(line)
+		}
+	}
+	f.Add("")
+	f.Add("ハーブティーを飲みます")
+	f.Add("abc123 ハーブです！？")
+
+	d, err := dict.NewUserDict("testdata/userdict/userdict.txt")
+	assert.NoError(f, err)
+	seed := int64(1)
+	var cs []*Converter
+	for _, opt := range []*ConvertOption{
+		{Seed: &seed},
+		{Seed: &seed, Level: LevelExtreme, UserDict: d},
+	} {
+		c, err := NewConverter(opt)
+		assert.NoError(f, err)
+		cs = append(cs, c)
+	}
+
+	f.Fuzz(func(t *testing.T, src string) {
+		for _, c := range cs {
+			got, err := c.Convert(src)
+			if !utf8.ValidString(src) || strings.ContainsRune(src, 0) {
+				var e *InvalidInputError
+				assert.True(t, errors.As(err, &e), "src = %q", src)
+				continue
+			}
+			assert.NoError(t, err, "src = %q", src)
+			assert.True(t, utf8.ValidString(got), "src = %q, got = %q", src, got)
+			assert.Equal(t, asciiAlnums(src), asciiAlnums(got), "src = %q, got = %q", src, got)
+		}
+	})
+}
+
+// asciiAlnums は s に含まれる半角英数字だけを順に連結して返す。
+func asciiAlnums(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
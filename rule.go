@@ -0,0 +1,578 @@
+package ojosama
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jiro4989/ojosama/internal/converter"
+)
+
+// Condition は変換ルールの条件。
+//
+// 値が設定されている項目だけを評価し、
+// 設定されているすべての項目が単語と一致した時に条件にマッチする。
+type Condition struct {
+	Features   []string       // 品詞。{"名詞", "一般"} のように先頭から順に比較する
+	Surface    string         // 表層形
+	SurfaceRe  *regexp.Regexp // 表層形の正規表現
+	Reading    string         // 読み
+	ReadingRe  *regexp.Regexp // 読みの正規表現
+	BaseForm   string         // 基本形
+	BaseFormRe *regexp.Regexp // 基本形の正規表現
+}
+
+// Rule は単独の単語に対して、Conditions がすべてマッチしたときに変換するルール。
+type Rule struct {
+	Conditions                   []Condition // 起点になる変換条件
+	BeforeIgnoreConditions       []Condition // 前の単語がいずれかの条件にマッチした場合は変換しない
+	AfterIgnoreConditions        []Condition // 次の単語がいずれかの条件にマッチした場合は変換しない
+	EnableWhenSentenceSeparation bool        // 文の区切り（単語の後に句点か読点がくる、あるいは何もない）場合だけ有効にする
+	AppendLongNote               bool        // 波線を追加する
+	DisablePrefix                bool        // 「お」を手前に付与しない
+	EnableKutenToExclamation     bool        // 直後に句点が来たとき確率で！に変換する
+	Value                        string      // この文字列に置換する。@1 は変換元の単語に置き換わる
+	Category                     Category    // 変換ルールの分類。未設定の場合は CategoryOther
+	ID                           string      // 変換ルールを一意に識別するID。ConvertOption.DisableRules で指定する
+	Description                  string      // 変換ルールの説明
+	Examples                     []Example   // 変換例
+}
+
+// ContinuousRule は連続する単語が、Conditions の順にすべてマッチしたときに変換するルール。
+//
+// Rule と違い、直後に句点が来たときに確率で！に変換するかどうかは指定できない。
+// 句点の変換を無効にしていなければ、連続する単語の直後の句点は常に確率で！に変換する。
+type ContinuousRule struct {
+	Conditions     []Condition // 連続する単語の変換条件
+	AppendLongNote bool        // 波線を追加する
+	Value          string      // この文字列に置換する。@1 は1つ目の単語に置き換わる
+	Category       Category    // 変換ルールの分類。未設定の場合は CategoryOther
+	ID             string      // 変換ルールを一意に識別するID。ConvertOption.DisableRules で指定する
+	Description    string      // 変換ルールの説明
+	Examples       []Example   // 変換例
+}
+
+// ExcludeRule は Conditions がすべてマッチした単語を変換せずにそのまま出力するルール。
+//
+// 「お」を付与したくない固有名詞などに使う。
+//
+// 分類は常に CategoryExclusion になる。
+type ExcludeRule struct {
+	Conditions  []Condition
+	ID          string    // 変換ルールを一意に識別するID。ConvertOption.DisableRules で指定する
+	Description string    // 変換ルールの説明
+	Examples    []Example // 変換例
+}
+
+// Example は変換ルールの変換例。
+//
+// 組み込みの変換ルールの変換例は、単体テストで実際に変換して確認している。
+type Example struct {
+	Input string // 変換前の文字列
+	Want  string // 変換後の文字列
+}
+
+// MeaningType は終助詞の意味分類。
+type MeaningType string
+
+const (
+	MeaningTypeHope        MeaningType = "hope"        // 希望
+	MeaningTypePoem        MeaningType = "poem"        // 詠嘆
+	MeaningTypeProhibition MeaningType = "prohibition" // 禁止
+	MeaningTypeCoercion    MeaningType = "coercion"    // 強制
+)
+
+// Category は変換ルールの分類。
+//
+// Level や ConvertOption.DisableCategories によって分類ごとに変換ルールを有効・無効にする。
+type Category string
+
+const (
+	CategoryOther         Category = "other"         // その他
+	CategoryPronoun       Category = "pronoun"       // 人称代名詞
+	CategoryDemonstrative Category = "demonstrative" // こそあど言葉
+	CategoryEnding        Category = "ending"        // 文末表現
+	CategoryAdjective     Category = "adjective"     // 形容詞文
+	CategoryInterjection  Category = "interjection"  // 感動詞
+	CategoryVulgar        Category = "vulgar"        // 下品な言葉
+	CategoryName          Category = "name"          // 固有名詞
+	CategoryExclusion     Category = "exclusion"     // 変換しない単語。除外ルールの分類
+)
+
+// SentenceEndingRule は「名詞」＋「動詞」＋「終助詞」の組み合わせによる変換ルール。
+//
+// 終助詞の意味分類ごとに、変換後の文字列を切り替える。
+type SentenceEndingRule struct {
+	Conditions1            []Condition                 // 一番最初に評価される条件
+	Conditions2            []Condition                 // 二番目に評価される条件
+	AuxiliaryVerb          []Condition                 // 助動詞。マッチしなくても次にすすむ
+	SentenceEndingParticle map[MeaningType][]Condition // 意味分類ごとの終助詞の条件
+	Priority               map[MeaningType]int         // 複数の意味分類の終助詞の条件にマッチする時の優先度。大きいほど優先する。無い場合は0
+	Value                  map[MeaningType][]string    // 意味分類ごとの変換後の文字列。複数ある場合はランダムに選択する
+	ValueWeights           map[MeaningType][]int       // Value と同じ並びの重み。無い場合は同じ確率で選択する
+	Category               Category                    // 変換ルールの分類。未設定の場合は CategoryOther
+	ID                     string                      // 変換ルールを一意に識別するID。ConvertOption.DisableRules で指定する
+	Description            string                      // 変換ルールの説明
+	Examples               []Example                   // 変換例
+}
+
+// RuleSet は独自の変換ルールの集合。
+//
+// 変換ルールは種類ごとに定義順に評価し、最初にマッチしたルールで変換する。
+type RuleSet struct {
+	ConvertRules        []Rule
+	ContinuousRules     []ContinuousRule
+	SentenceEndingRules []SentenceEndingRule
+	ExcludeRules        []ExcludeRule
+}
+
+// BuiltinRules は組み込みの変換ルールを返す。
+//
+// 返却する変換ルールは変換エンジンが使うものと同じ内容のコピーのため、
+// 書き換えても変換結果には影響しない。
+func BuiltinRules() *RuleSet {
+	var rs RuleSet
+	for _, r := range converter.ConvertRules {
+		rs.ConvertRules = append(rs.ConvertRules, fromInternalRule(r))
+	}
+	for _, r := range converter.ContinuousConditionsConvertRules {
+		rs.ContinuousRules = append(rs.ContinuousRules, fromInternalContinuousRule(r))
+	}
+	for _, r := range converter.SentenceEndingParticleConvertRules {
+		rs.SentenceEndingRules = append(rs.SentenceEndingRules, fromInternalSentenceEndingRule(r))
+	}
+	for _, r := range converter.ExcludeRules {
+		rs.ExcludeRules = append(rs.ExcludeRules, fromInternalExcludeRule(r))
+	}
+	return &rs
+}
+
+// Validate は変換ルールが正しく定義されているかを検証する。
+//
+// 条件が1つもない変換ルールはすべての単語にマッチしてしまうため、エラーとして扱う。
+func (rs *RuleSet) Validate() error {
+	if rs == nil {
+		return nil
+	}
+	for i, r := range rs.ConvertRules {
+		if len(r.Conditions) < 1 {
+			return fmt.Errorf("convert rule %d: %w", i, errEmptyConditions)
+		}
+		if err := r.Category.validate(); err != nil {
+			return fmt.Errorf("convert rule %d: %w", i, err)
+		}
+	}
+	for i, r := range rs.ContinuousRules {
+		if len(r.Conditions) < 1 {
+			return fmt.Errorf("continuous rule %d: %w", i, errEmptyConditions)
+		}
+		if err := r.Category.validate(); err != nil {
+			return fmt.Errorf("continuous rule %d: %w", i, err)
+		}
+	}
+	for i, r := range rs.SentenceEndingRules {
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("sentence ending rule %d: %w", i, err)
+		}
+	}
+	for i, r := range rs.ExcludeRules {
+		if len(r.Conditions) < 1 {
+			return fmt.Errorf("exclude rule %d: %w", i, errEmptyConditions)
+		}
+	}
+	return nil
+}
+
+func (r SentenceEndingRule) validate() error {
+	if len(r.Conditions1) < 1 || len(r.Conditions2) < 1 || len(r.SentenceEndingParticle) < 1 {
+		return errEmptyConditions
+	}
+	if err := r.Category.validate(); err != nil {
+		return err
+	}
+	for mt := range r.SentenceEndingParticle {
+		if err := mt.validate(); err != nil {
+			return err
+		}
+	}
+	for mt := range r.Priority {
+		if err := mt.validate(); err != nil {
+			return err
+		}
+	}
+	// 同じ終助詞に複数の意味分類がマッチしうる場合は、優先度で順序を決める必要がある
+	if a, b, ok := r.toInternal().SentenceEndingParticle.FindAmbiguousMeaningTypes(); ok {
+		return fmt.Errorf("%w: %s and %s", errAmbiguousMeaningType, a, b)
+	}
+	for mt := range r.Value {
+		if err := mt.validate(); err != nil {
+			return err
+		}
+	}
+	for mt, weights := range r.ValueWeights {
+		if err := mt.validate(); err != nil {
+			return err
+		}
+		if err := validateValueWeights(weights, len(r.Value[mt])); err != nil {
+			return fmt.Errorf("%w: %s", err, mt)
+		}
+	}
+	return nil
+}
+
+// validateValueWeights は変換候補の重みを検証する。
+//
+// 重みは変換候補と同じ数だけ必要で、負の値は指定できない。
+// いずれかの変換候補の重みが1以上でなければならない。
+func validateValueWeights(weights []int, n int) error {
+	if len(weights) != n {
+		return fmt.Errorf("%w: %d weights for %d values", errInvalidValueWeight, len(weights), n)
+	}
+	var total int
+	for _, w := range weights {
+		if w < 0 {
+			return fmt.Errorf("%w: %d", errInvalidValueWeight, w)
+		}
+		total += w
+	}
+	if total < 1 {
+		return fmt.Errorf("%w: total must be positive", errInvalidValueWeight)
+	}
+	return nil
+}
+
+func (m MeaningType) validate() error {
+	if _, ok := converter.ParseMeaningType(string(m)); !ok {
+		return fmt.Errorf("%w: %q", errUnknownMeaningType, m)
+	}
+	return nil
+}
+
+// validate は分類が既知の分類かを検証する。未設定の場合は CategoryOther として扱う。
+func (c Category) validate() error {
+	if c == "" {
+		return nil
+	}
+	if _, ok := converter.ParseCategory(string(c)); !ok {
+		return fmt.Errorf("%w: %q (must be one of %s)", errUnknownCategory, c, strings.Join(converter.CategoryNames(), ", "))
+	}
+	return nil
+}
+
+// toInternal は分類を変換エンジンの形式に変換する。
+//
+// 不明な分類は Validate で事前にエラーにしているため、ここでは CategoryOther として扱う。
+func (c Category) toInternal() converter.Category {
+	cat, _ := converter.ParseCategory(string(c))
+	return cat
+}
+
+func fromInternalCategory(c converter.Category) Category {
+	return Category(c.String())
+}
+
+var (
+	errEmptyConditions    = errors.New("conditions must not be empty")
+	errUnknownMeaningType = errors.New("unknown meaning type")
+	errUnknownCategory    = errors.New("unknown category")
+	errInvalidValueWeight = errors.New("invalid value weight")
+
+	errAmbiguousMeaningType = errors.New("sentence ending particle matches multiple meaning types with the same priority")
+)
+
+func (c Condition) toInternal() converter.ConvertCondition {
+	return converter.ConvertCondition{
+		Features:   copyStrings(c.Features),
+		Surface:    c.Surface,
+		SurfaceRe:  c.SurfaceRe,
+		Reading:    c.Reading,
+		ReadingRe:  c.ReadingRe,
+		BaseForm:   c.BaseForm,
+		BaseFormRe: c.BaseFormRe,
+	}
+}
+
+func toInternalConditions(conds []Condition) converter.ConvertConditions {
+	if conds == nil {
+		return nil
+	}
+	result := make(converter.ConvertConditions, 0, len(conds))
+	for _, c := range conds {
+		result = append(result, c.toInternal())
+	}
+	return result
+}
+
+func (r Rule) toInternal() converter.ConvertRule {
+	return converter.ConvertRule{
+		Conditions:                   toInternalConditions(r.Conditions),
+		BeforeIgnoreConditions:       toInternalConditions(r.BeforeIgnoreConditions),
+		AfterIgnoreConditions:        toInternalConditions(r.AfterIgnoreConditions),
+		EnableWhenSentenceSeparation: r.EnableWhenSentenceSeparation,
+		AppendLongNote:               r.AppendLongNote,
+		DisablePrefix:                r.DisablePrefix,
+		EnableKutenToExclamation:     r.EnableKutenToExclamation,
+		Value:                        r.Value,
+		Category:                     r.Category.toInternal(),
+		ID:                           r.ID,
+		Description:                  r.Description,
+		Examples:                     toInternalExamples(r.Examples),
+	}
+}
+
+func (r ContinuousRule) toInternal() converter.ContinuousConditionsConvertRule {
+	return converter.ContinuousConditionsConvertRule{
+		Conditions:     toInternalConditions(r.Conditions),
+		AppendLongNote: r.AppendLongNote,
+		Value:          r.Value,
+		Category:       r.Category.toInternal(),
+		ID:             r.ID,
+		Description:    r.Description,
+		Examples:       toInternalExamples(r.Examples),
+	}
+}
+
+// toInternal は変換ルールを変換エンジンの形式に変換する。
+//
+// 不明な意味分類は Validate で事前にエラーにしているため、ここでは無視する。
+func (r SentenceEndingRule) toInternal() converter.SentenceEndingParticleConvertRule {
+	result := converter.SentenceEndingParticleConvertRule{
+		Conditions1:   toInternalConditions(r.Conditions1),
+		Conditions2:   toInternalConditions(r.Conditions2),
+		AuxiliaryVerb: toInternalConditions(r.AuxiliaryVerb),
+		Category:      r.Category.toInternal(),
+		ID:            r.ID,
+		Description:   r.Description,
+		Examples:      toInternalExamples(r.Examples),
+	}
+	if r.SentenceEndingParticle != nil {
+		result.SentenceEndingParticle = make(converter.SentenceEndingParticleConditions, 0, len(r.SentenceEndingParticle))
+		for k, v := range r.SentenceEndingParticle {
+			if mt, ok := converter.ParseMeaningType(string(k)); ok {
+				result.SentenceEndingParticle = append(result.SentenceEndingParticle, converter.SentenceEndingParticleCondition{
+					MeaningType: mt,
+					Priority:    r.Priority[k],
+					Conditions:  toInternalConditions(v),
+				})
+			}
+		}
+		// マップの走査順に依存しないように、意味分類の定義順に並べる
+		sort.Slice(result.SentenceEndingParticle, func(i, j int) bool {
+			return result.SentenceEndingParticle[i].MeaningType < result.SentenceEndingParticle[j].MeaningType
+		})
+	}
+	if r.Value != nil {
+		result.Value = make(map[converter.MeaningType][]string, len(r.Value))
+		for k, v := range r.Value {
+			if mt, ok := converter.ParseMeaningType(string(k)); ok {
+				result.Value[mt] = copyStrings(v)
+			}
+		}
+	}
+	if r.ValueWeights != nil {
+		result.ValueWeights = make(map[converter.MeaningType][]int, len(r.ValueWeights))
+		for k, v := range r.ValueWeights {
+			if mt, ok := converter.ParseMeaningType(string(k)); ok {
+				result.ValueWeights[mt] = slices.Clone(v)
+			}
+		}
+	}
+	return result
+}
+
+func (r ExcludeRule) toInternal() converter.ConvertRule {
+	return converter.ConvertRule{
+		Conditions:  toInternalConditions(r.Conditions),
+		Category:    converter.CategoryExclusion,
+		ID:          r.ID,
+		Description: r.Description,
+		Examples:    toInternalExamples(r.Examples),
+	}
+}
+
+func toInternalExamples(examples []Example) []converter.Example {
+	if examples == nil {
+		return nil
+	}
+	result := make([]converter.Example, 0, len(examples))
+	for _, e := range examples {
+		result = append(result, converter.Example{Input: e.Input, Want: e.Want})
+	}
+	return result
+}
+
+func (rs *RuleSet) convertRules() []converter.ConvertRule {
+	if rs == nil {
+		return nil
+	}
+	var result []converter.ConvertRule
+	for _, r := range rs.ConvertRules {
+		result = append(result, r.toInternal())
+	}
+	return result
+}
+
+func (rs *RuleSet) continuousRules() []converter.ContinuousConditionsConvertRule {
+	if rs == nil {
+		return nil
+	}
+	var result []converter.ContinuousConditionsConvertRule
+	for _, r := range rs.ContinuousRules {
+		result = append(result, r.toInternal())
+	}
+	return result
+}
+
+func (rs *RuleSet) sentenceEndingRules() []converter.SentenceEndingParticleConvertRule {
+	if rs == nil {
+		return nil
+	}
+	var result []converter.SentenceEndingParticleConvertRule
+	for _, r := range rs.SentenceEndingRules {
+		result = append(result, r.toInternal())
+	}
+	return result
+}
+
+func (rs *RuleSet) excludeRules() []converter.ConvertRule {
+	if rs == nil {
+		return nil
+	}
+	var result []converter.ConvertRule
+	for _, r := range rs.ExcludeRules {
+		result = append(result, r.toInternal())
+	}
+	return result
+}
+
+func fromInternalCondition(c converter.ConvertCondition) Condition {
+	return Condition{
+		Features:   copyStrings(c.Features),
+		Surface:    c.Surface,
+		SurfaceRe:  c.SurfaceRe,
+		Reading:    c.Reading,
+		ReadingRe:  c.ReadingRe,
+		BaseForm:   c.BaseForm,
+		BaseFormRe: c.BaseFormRe,
+	}
+}
+
+func fromInternalConditions(conds converter.ConvertConditions) []Condition {
+	if conds == nil {
+		return nil
+	}
+	result := make([]Condition, 0, len(conds))
+	for _, c := range conds {
+		result = append(result, fromInternalCondition(c))
+	}
+	return result
+}
+
+func fromInternalRule(r converter.ConvertRule) Rule {
+	return Rule{
+		Conditions:                   fromInternalConditions(r.Conditions),
+		BeforeIgnoreConditions:       fromInternalConditions(r.BeforeIgnoreConditions),
+		AfterIgnoreConditions:        fromInternalConditions(r.AfterIgnoreConditions),
+		EnableWhenSentenceSeparation: r.EnableWhenSentenceSeparation,
+		AppendLongNote:               r.AppendLongNote,
+		DisablePrefix:                r.DisablePrefix,
+		EnableKutenToExclamation:     r.EnableKutenToExclamation,
+		Value:                        r.Value,
+		Category:                     fromInternalCategory(r.Category),
+		ID:                           r.ID,
+		Description:                  r.Description,
+		Examples:                     fromInternalExamples(r.Examples),
+	}
+}
+
+func fromInternalContinuousRule(r converter.ContinuousConditionsConvertRule) ContinuousRule {
+	return ContinuousRule{
+		Conditions:     fromInternalConditions(r.Conditions),
+		AppendLongNote: r.AppendLongNote,
+		Value:          r.Value,
+		Category:       fromInternalCategory(r.Category),
+		ID:             r.ID,
+		Description:    r.Description,
+		Examples:       fromInternalExamples(r.Examples),
+	}
+}
+
+func fromInternalSentenceEndingRule(r converter.SentenceEndingParticleConvertRule) SentenceEndingRule {
+	result := SentenceEndingRule{
+		Conditions1:   fromInternalConditions(r.Conditions1),
+		Conditions2:   fromInternalConditions(r.Conditions2),
+		AuxiliaryVerb: fromInternalConditions(r.AuxiliaryVerb),
+		Category:      fromInternalCategory(r.Category),
+		ID:            r.ID,
+		Description:   r.Description,
+		Examples:      fromInternalExamples(r.Examples),
+	}
+	if r.SentenceEndingParticle != nil {
+		result.SentenceEndingParticle = make(map[MeaningType][]Condition, len(r.SentenceEndingParticle))
+		for _, v := range r.SentenceEndingParticle {
+			mt := MeaningType(v.MeaningType.String())
+			result.SentenceEndingParticle[mt] = fromInternalConditions(v.Conditions)
+			if v.Priority != 0 {
+				if result.Priority == nil {
+					result.Priority = make(map[MeaningType]int)
+				}
+				result.Priority[mt] = v.Priority
+			}
+		}
+	}
+	if r.Value != nil {
+		result.Value = make(map[MeaningType][]string, len(r.Value))
+		for k, v := range r.Value {
+			result.Value[MeaningType(k.String())] = copyStrings(v)
+		}
+	}
+	if r.ValueWeights != nil {
+		result.ValueWeights = make(map[MeaningType][]int, len(r.ValueWeights))
+		for k, v := range r.ValueWeights {
+			result.ValueWeights[MeaningType(k.String())] = append([]int{}, v...)
+		}
+	}
+	return result
+}
+
+func fromInternalExcludeRule(r converter.ConvertRule) ExcludeRule {
+	return ExcludeRule{
+		Conditions:  fromInternalConditions(r.Conditions),
+		ID:          r.ID,
+		Description: r.Description,
+		Examples:    fromInternalExamples(r.Examples),
+	}
+}
+
+func fromInternalExamples(examples []converter.Example) []Example {
+	if examples == nil {
+		return nil
+	}
+	result := make([]Example, 0, len(examples))
+	for _, e := range examples {
+		result = append(result, Example{Input: e.Input, Want: e.Want})
+	}
+	return result
+}
+
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
+// concatRules は前に追加するルール、組み込みのルール、後ろに追加するルールの順に連結する。
+func concatRules[T any](prepend, builtin, append_ []T) []T {
+	if len(prepend) < 1 && len(append_) < 1 {
+		return builtin
+	}
+	result := make([]T, 0, len(prepend)+len(builtin)+len(append_))
+	result = append(result, prepend...)
+	result = append(result, builtin...)
+	result = append(result, append_...)
+	return result
+}
@@ -0,0 +1,432 @@
+package ojosama
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertWithCustomRules(t *testing.T) {
+	tests := []struct {
+		desc    string
+		src     string
+		opt     *ConvertOption
+		want    string
+		wantErr bool
+	}{
+		{
+			desc: "正常系: 前に追加したルールは組み込みのルールよりも優先いたしますわ",
+			src:  "俺はハーブです",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				PrependRules: &RuleSet{
+					ConvertRules: []Rule{
+						{
+							Conditions: []Condition{
+								{Features: []string{"名詞", "代名詞", "一般"}, Surface: "俺"},
+							},
+							Value: "ワタクシ",
+						},
+					},
+				},
+			},
+			want:    "ワタクシはおハーブですわ",
+			wantErr: false,
+		},
+		{
+			desc: "正常系: 後ろに追加したルールは組み込みのルールにマッチしなかった時に評価いたしますわ",
+			src:  "俺はハーブです",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				AppendRules: &RuleSet{
+					ConvertRules: []Rule{
+						{
+							Conditions: []Condition{
+								{Surface: "俺"},
+							},
+							Value: "ワタクシ",
+						},
+						{
+							Conditions: []Condition{
+								{SurfaceRe: regexp.MustCompile(`^ハーブ$`)},
+							},
+							Value:         "薬草",
+							DisablePrefix: true,
+						},
+					},
+				},
+			},
+			want:    "私は薬草ですわ",
+			wantErr: false,
+		},
+		{
+			desc: "正常系: 除外ルールにマッチした単語には「お」を付けませんわ",
+			src:  "これはハーブです",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				PrependRules: &RuleSet{
+					ExcludeRules: []ExcludeRule{
+						{
+							Conditions: []Condition{
+								{Surface: "ハーブ"},
+							},
+						},
+					},
+				},
+			},
+			want:    "こちらはハーブですわ",
+			wantErr: false,
+		},
+		{
+			desc: "正常系: 連続する条件のルールも追加できますわ",
+			src:  "田中さんです",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				PrependRules: &RuleSet{
+					ContinuousRules: []ContinuousRule{
+						{
+							Conditions: []Condition{
+								{Surface: "田中"},
+								{Surface: "さん"},
+							},
+							Value: "田中様",
+						},
+					},
+				},
+			},
+			want:    "田中様ですわ",
+			wantErr: false,
+		},
+		{
+			desc: "正常系: 複数の意味分類にマッチする終助詞は優先度の大きい意味分類で変換いたしますわ",
+			src:  "野球しようぜ",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				PrependRules: &RuleSet{
+					SentenceEndingRules: []SentenceEndingRule{
+						{
+							Conditions1:   []Condition{{Features: []string{"名詞", "一般"}}},
+							Conditions2:   []Condition{{BaseForm: "する"}},
+							AuxiliaryVerb: []Condition{{Surface: "う"}},
+							SentenceEndingParticle: map[MeaningType][]Condition{
+								MeaningTypeHope:     {{Surface: "ぜ"}},
+								MeaningTypeCoercion: {{Surface: "ぜ"}},
+							},
+							Priority: map[MeaningType]int{MeaningTypeCoercion: 1},
+							Value: map[MeaningType][]string{
+								MeaningTypeHope:     {"をいたしませんこと"},
+								MeaningTypeCoercion: {"をいたしますわよ"},
+							},
+						},
+					},
+				},
+			},
+			want:    "お野球をいたしますわよ",
+			wantErr: false,
+		},
+		{
+			desc: "異常系: 条件のないルールはエラーになりますわ",
+			src:  "これはハーブです",
+			opt: &ConvertOption{
+				AppendRules: &RuleSet{
+					ConvertRules: []Rule{
+						{Value: "ハーブ"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := Convert(tt.src, tt.opt)
+			if tt.wantErr {
+				assert.Error(err)
+				assert.Empty(got)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tt.want, got)
+
+			// Converter でも同じ結果になりますわ
+			c, err := NewConverter(tt.opt)
+			assert.NoError(err)
+			got, err = c.Convert(tt.src)
+			assert.NoError(err)
+			assert.Equal(tt.want, got)
+		})
+	}
+}
+
+func TestRuleSetValidate(t *testing.T) {
+	tests := []struct {
+		desc    string
+		rs      *RuleSet
+		wantErr bool
+	}{
+		{
+			desc:    "正常系: nilは問題ありませんわ",
+			rs:      nil,
+			wantErr: false,
+		},
+		{
+			desc: "正常系: 条件のあるルールは問題ありませんわ",
+			rs: &RuleSet{
+				ConvertRules:    []Rule{{Conditions: []Condition{{Surface: "a"}}}},
+				ContinuousRules: []ContinuousRule{{Conditions: []Condition{{Surface: "a"}}}},
+				ExcludeRules:    []ExcludeRule{{Conditions: []Condition{{Surface: "a"}}}},
+			},
+			wantErr: false,
+		},
+		{
+			desc: "異常系: 条件のない連続する条件のルールはエラーですわ",
+			rs: &RuleSet{
+				ContinuousRules: []ContinuousRule{{Value: "a"}},
+			},
+			wantErr: true,
+		},
+		{
+			desc: "異常系: 条件のない除外ルールはエラーですわ",
+			rs: &RuleSet{
+				ExcludeRules: []ExcludeRule{{}},
+			},
+			wantErr: true,
+		},
+		{
+			desc: "異常系: 不明な意味分類の文末の変換ルールはエラーですわ",
+			rs: &RuleSet{
+				SentenceEndingRules: []SentenceEndingRule{
+					{
+						Conditions1:            []Condition{{Surface: "a"}},
+						Conditions2:            []Condition{{Surface: "b"}},
+						SentenceEndingParticle: map[MeaningType][]Condition{"hoge": {{Surface: "c"}}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			desc: "異常系: 重みの合計が0の文末の変換ルールはエラーですわ",
+			rs: &RuleSet{
+				SentenceEndingRules: []SentenceEndingRule{
+					{
+						Conditions1:            []Condition{{Surface: "a"}},
+						Conditions2:            []Condition{{Surface: "b"}},
+						SentenceEndingParticle: map[MeaningType][]Condition{MeaningTypeHope: {{Surface: "c"}}},
+						Value:                  map[MeaningType][]string{MeaningTypeHope: {"d", "e"}},
+						ValueWeights:           map[MeaningType][]int{MeaningTypeHope: {0, 0}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			desc: "正常系: 同じ終助詞に複数の意味分類がマッチしうる場合も優先度が違えば問題ありませんわ",
+			rs: &RuleSet{
+				SentenceEndingRules: []SentenceEndingRule{
+					{
+						Conditions1: []Condition{{Surface: "a"}},
+						Conditions2: []Condition{{Surface: "b"}},
+						SentenceEndingParticle: map[MeaningType][]Condition{
+							MeaningTypeHope:     {{Surface: "よ"}},
+							MeaningTypeCoercion: {{Surface: "よ"}},
+						},
+						Priority: map[MeaningType]int{MeaningTypeCoercion: 1},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			desc: "異常系: 同じ終助詞に優先度が同じ意味分類がマッチしうる場合はエラーですわ",
+			rs: &RuleSet{
+				SentenceEndingRules: []SentenceEndingRule{
+					{
+						Conditions1: []Condition{{Surface: "a"}},
+						Conditions2: []Condition{{Surface: "b"}},
+						SentenceEndingParticle: map[MeaningType][]Condition{
+							MeaningTypeHope:     {{Surface: "よ"}},
+							MeaningTypeCoercion: {{Surface: "よ"}},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			err := tt.rs.Validate()
+			if tt.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestBuiltinRuleExamples(t *testing.T) {
+	type example struct {
+		id string
+		Example
+	}
+	var examples []example
+	rs := BuiltinRules()
+	for _, r := range rs.SentenceEndingRules {
+		for _, e := range r.Examples {
+			examples = append(examples, example{id: r.ID, Example: e})
+		}
+	}
+	for _, r := range rs.ContinuousRules {
+		for _, e := range r.Examples {
+			examples = append(examples, example{id: r.ID, Example: e})
+		}
+	}
+	for _, r := range rs.ExcludeRules {
+		for _, e := range r.Examples {
+			examples = append(examples, example{id: r.ID, Example: e})
+		}
+	}
+	for _, r := range rs.ConvertRules {
+		for _, e := range r.Examples {
+			examples = append(examples, example{id: r.ID, Example: e})
+		}
+	}
+
+	// 変換例の通りに変換できますわ
+	opt := &ConvertOption{
+		DisableKutenToExclamation: true,
+		DisableLongNote:           true,
+		DisableEndingVariation:    true,
+	}
+	for _, e := range examples {
+		t.Run(e.id+": "+e.Input, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, traces, err := ConvertWithTrace(e.Input, opt)
+			assert.NoError(err)
+			assert.Equal(e.Want, got)
+
+			// 変換例の変換ルールが使われていますわ
+			var ids []string
+			for _, ts := range traces {
+				ids = append(ids, ts.RuleID)
+			}
+			assert.Contains(ids, e.id)
+		})
+	}
+}
+
+func TestConvertDisableRules(t *testing.T) {
+	tests := []struct {
+		desc    string
+		src     string
+		opt     *ConvertOption
+		want    string
+		wantErr bool
+	}{
+		{
+			desc: "正常系: IDを指定した変換ルールは使いませんわ",
+			src:  "これは何か",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				DisableRules:              []string{"ending.か"},
+			},
+			want:    "こちらは何か",
+			wantErr: false,
+		},
+		{
+			desc: "正常系: 分類を指定した変換ルールは使いませんわ",
+			src:  "これは何か",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				DisableCategories:         []Category{CategoryDemonstrative},
+			},
+			want:    "これは何の",
+			wantErr: false,
+		},
+		{
+			desc: "正常系: 除外ルールも無効にできますわ",
+			src:  "カスです",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				DisableCategories:         []Category{CategoryExclusion},
+			},
+			want:    "おカスですわ",
+			wantErr: false,
+		},
+		{
+			desc: "正常系: 形容詞文の変換ルールも分類で無効にできますわ",
+			src:  "ハーブは美しい",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				DisableCategories:         []Category{CategoryAdjective},
+			},
+			want:    "おハーブは美しい",
+			wantErr: false,
+		},
+		{
+			desc: "正常系: 独自の変換ルールのIDも指定できますわ",
+			src:  "俺はハーブです",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				PrependRules: &RuleSet{
+					ConvertRules: []Rule{
+						{
+							Conditions: []Condition{{Surface: "俺"}},
+							Value:      "ワタクシ",
+							ID:         "custom.俺",
+						},
+					},
+				},
+				DisableRules: []string{"custom.俺"},
+			},
+			want:    "私はおハーブですわ",
+			wantErr: false,
+		},
+		{
+			desc: "異常系: 存在しないIDはエラーですわ",
+			src:  "これは何か",
+			opt: &ConvertOption{
+				DisableRules: []string{"ending.存在しない"},
+			},
+			wantErr: true,
+		},
+		{
+			desc: "異常系: 存在しない分類はエラーですわ",
+			src:  "これは何か",
+			opt: &ConvertOption{
+				DisableCategories: []Category{"hoge"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := Convert(tt.src, tt.opt)
+			if tt.wantErr {
+				assert.Error(err)
+				assert.Empty(got)
+
+				_, err = NewConverter(tt.opt)
+				assert.Error(err)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,655 @@
+package ojosama
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RuleFileFormat は変換ルールファイルの形式。
+type RuleFileFormat int
+
+const (
+	RuleFileFormatYAML RuleFileFormat = iota
+	RuleFileFormatJSON
+)
+
+// RuleFileError は変換ルールファイルの内容が不正な時のエラー。
+type RuleFileError struct {
+	Line int   // エラーが発生した行番号。1始まり
+	Err  error // エラーの内容
+}
+
+func (e *RuleFileError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+func (e *RuleFileError) Unwrap() error {
+	return e.Err
+}
+
+// ruleFile は変換ルールファイルの構造。
+//
+// 変換ルールファイルの形式は以下の通り。
+// JSON の場合もキー名は同じ。
+//
+//	convert_rules:
+//	  - id: pronoun.俺
+//	    description: 「俺」を「ワタクシ」に変換する
+//	    conditions:
+//	      - features: [名詞, 代名詞, 一般]
+//	        surface: 俺
+//	    before_ignore_conditions: []
+//	    after_ignore_conditions: []
+//	    enable_when_sentence_separation: false
+//	    append_long_note: false
+//	    disable_prefix: false
+//	    enable_kuten_to_exclamation: false
+//	    value: ワタクシ
+//	    category: pronoun
+//	    examples:
+//	      - input: 俺はハーブです
+//	        want: ワタクシはおハーブですわ
+//	continuous_rules:
+//	  - conditions:
+//	      - surface: 田中
+//	      - surface: さん
+//	    append_long_note: false
+//	    value: 田中様
+//	sentence_ending_rules:
+//	  - conditions1:
+//	      - features: [名詞, 一般]
+//	    conditions2:
+//	      - features: [動詞, 自立]
+//	        base_form: する
+//	    auxiliary_verb:
+//	      - features: [助動詞]
+//	        surface: う
+//	    sentence_ending_particle:
+//	      hope:
+//	        - features: [助詞, 終助詞]
+//	          surface: よ
+//	    value:
+//	      hope: [をいたしませんこと, をいたしましょう]
+//	    value_weights:
+//	      hope: [3, 1]
+//	exclude_rules:
+//	  - conditions:
+//	      - surface_re: ^ハーブ$
+//
+// 条件には features, surface, surface_re, reading, reading_re, base_form,
+// base_form_re を指定できる。 *_re は正規表現。
+// 終助詞の意味分類には hope, poem, prohibition, coercion を指定できる。
+// 分類には other, pronoun, demonstrative, ending, adjective, interjection, vulgar, name, exclusion を指定できる。
+// continuous_rules には enable_kuten_to_exclamation を指定できず、直後の句点は常に確率で！に変換する。
+// 同じ終助詞に複数の意味分類がマッチしうる場合は priority で意味分類ごとの優先度を指定する。
+// value に複数の候補を指定した場合はランダムに選択する。 value_weights で候補ごとの重みを指定できる。
+// id, description, examples は省略できる。 id は ConvertOption.DisableRules で指定する。
+type ruleFile struct {
+	ConvertRules        []ruleFileRule               `yaml:"convert_rules,omitempty" json:"convert_rules,omitempty"`
+	ContinuousRules     []ruleFileContinuousRule     `yaml:"continuous_rules,omitempty" json:"continuous_rules,omitempty"`
+	SentenceEndingRules []ruleFileSentenceEndingRule `yaml:"sentence_ending_rules,omitempty" json:"sentence_ending_rules,omitempty"`
+	ExcludeRules        []ruleFileExcludeRule        `yaml:"exclude_rules,omitempty" json:"exclude_rules,omitempty"`
+}
+
+type ruleFileCondition struct {
+	Features   []string `yaml:"features,flow,omitempty" json:"features,omitempty"`
+	Surface    string   `yaml:"surface,omitempty" json:"surface,omitempty"`
+	SurfaceRe  string   `yaml:"surface_re,omitempty" json:"surface_re,omitempty"`
+	Reading    string   `yaml:"reading,omitempty" json:"reading,omitempty"`
+	ReadingRe  string   `yaml:"reading_re,omitempty" json:"reading_re,omitempty"`
+	BaseForm   string   `yaml:"base_form,omitempty" json:"base_form,omitempty"`
+	BaseFormRe string   `yaml:"base_form_re,omitempty" json:"base_form_re,omitempty"`
+}
+
+type ruleFileRule struct {
+	ID                           string              `yaml:"id,omitempty" json:"id,omitempty"`
+	Description                  string              `yaml:"description,omitempty" json:"description,omitempty"`
+	Conditions                   []ruleFileCondition `yaml:"conditions" json:"conditions"`
+	BeforeIgnoreConditions       []ruleFileCondition `yaml:"before_ignore_conditions,omitempty" json:"before_ignore_conditions,omitempty"`
+	AfterIgnoreConditions        []ruleFileCondition `yaml:"after_ignore_conditions,omitempty" json:"after_ignore_conditions,omitempty"`
+	EnableWhenSentenceSeparation bool                `yaml:"enable_when_sentence_separation,omitempty" json:"enable_when_sentence_separation,omitempty"`
+	AppendLongNote               bool                `yaml:"append_long_note,omitempty" json:"append_long_note,omitempty"`
+	DisablePrefix                bool                `yaml:"disable_prefix,omitempty" json:"disable_prefix,omitempty"`
+	EnableKutenToExclamation     bool                `yaml:"enable_kuten_to_exclamation,omitempty" json:"enable_kuten_to_exclamation,omitempty"`
+	Value                        string              `yaml:"value" json:"value"`
+	Category                     string              `yaml:"category,omitempty" json:"category,omitempty"`
+	Examples                     []ruleFileExample   `yaml:"examples,omitempty" json:"examples,omitempty"`
+}
+
+type ruleFileContinuousRule struct {
+	ID             string              `yaml:"id,omitempty" json:"id,omitempty"`
+	Description    string              `yaml:"description,omitempty" json:"description,omitempty"`
+	Conditions     []ruleFileCondition `yaml:"conditions" json:"conditions"`
+	AppendLongNote bool                `yaml:"append_long_note,omitempty" json:"append_long_note,omitempty"`
+	Value          string              `yaml:"value" json:"value"`
+	Category       string              `yaml:"category,omitempty" json:"category,omitempty"`
+	Examples       []ruleFileExample   `yaml:"examples,omitempty" json:"examples,omitempty"`
+}
+
+type ruleFileSentenceEndingRule struct {
+	ID                     string                         `yaml:"id,omitempty" json:"id,omitempty"`
+	Description            string                         `yaml:"description,omitempty" json:"description,omitempty"`
+	Conditions1            []ruleFileCondition            `yaml:"conditions1" json:"conditions1"`
+	Conditions2            []ruleFileCondition            `yaml:"conditions2" json:"conditions2"`
+	AuxiliaryVerb          []ruleFileCondition            `yaml:"auxiliary_verb,omitempty" json:"auxiliary_verb,omitempty"`
+	SentenceEndingParticle map[string][]ruleFileCondition `yaml:"sentence_ending_particle" json:"sentence_ending_particle"`
+	Priority               map[string]int                 `yaml:"priority,omitempty" json:"priority,omitempty"`
+	Value                  map[string][]string            `yaml:"value" json:"value"`
+	ValueWeights           map[string][]int               `yaml:"value_weights,omitempty" json:"value_weights,omitempty"`
+	Category               string                         `yaml:"category,omitempty" json:"category,omitempty"`
+	Examples               []ruleFileExample              `yaml:"examples,omitempty" json:"examples,omitempty"`
+}
+
+type ruleFileExcludeRule struct {
+	ID          string              `yaml:"id,omitempty" json:"id,omitempty"`
+	Description string              `yaml:"description,omitempty" json:"description,omitempty"`
+	Conditions  []ruleFileCondition `yaml:"conditions" json:"conditions"`
+	Examples    []ruleFileExample   `yaml:"examples,omitempty" json:"examples,omitempty"`
+}
+
+type ruleFileExample struct {
+	Input string `yaml:"input" json:"input"`
+	Want  string `yaml:"want" json:"want"`
+}
+
+// LoadRuleFile は変換ルールファイルを読み込む。
+//
+// ファイルの形式は拡張子で判定する。
+// 拡張子が .json の場合は JSON 、それ以外は YAML として読み込む。
+func LoadRuleFile(path string) (*RuleSet, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	format := RuleFileFormatYAML
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		format = RuleFileFormatJSON
+	}
+
+	rs, err := ParseRuleFile(b, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rs, nil
+}
+
+// ParseRuleFile は format 形式の変換ルールファイルの内容を解析する。
+//
+// 内容が不正な場合は、問題のある行番号をエラーに含めて返す。
+func ParseRuleFile(b []byte, format RuleFileFormat) (*RuleSet, error) {
+	if format == RuleFileFormatJSON {
+		// JSON は YAML として解析できるが、JSON として不正な内容を
+		// 受け付けないように先に JSON として検証する
+		if err := validateJSON(b); err != nil {
+			return nil, err
+		}
+	}
+
+	// 行番号を取得するために、構造体とは別にノードとしても解析する
+	var root yaml.Node
+	if err := yaml.Unmarshal(b, &root); err != nil {
+		return nil, err
+	}
+
+	var rf ruleFile
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&rf); err != nil && !errors.Is(err, errEOF) {
+		return nil, err
+	}
+
+	return rf.toRuleSet(newRuleFileNode(&root))
+}
+
+// errEOF は空のファイルを読み込んだ時に yaml.Decoder が返すエラー。
+var errEOF = func() error {
+	var v any
+	return yaml.NewDecoder(bytes.NewReader(nil)).Decode(&v)
+}()
+
+// validateJSON は b が JSON として正しいかを検証する。
+func validateJSON(b []byte) error {
+	var v any
+	err := json.Unmarshal(b, &v)
+	if err == nil {
+		return nil
+	}
+
+	var se *json.SyntaxError
+	if errors.As(err, &se) {
+		line := bytes.Count(b[:se.Offset], []byte("\n")) + 1
+		return &RuleFileError{Line: line, Err: se}
+	}
+	return err
+}
+
+func (rf *ruleFile) toRuleSet(root ruleFileNode) (*RuleSet, error) {
+	var rs RuleSet
+
+	for i, r := range rf.ConvertRules {
+		n := root.get("convert_rules").index(i)
+		var rule Rule
+		var err error
+		if rule.Conditions, err = toConditions(r.Conditions, n, "conditions"); err != nil {
+			return nil, err
+		}
+		if rule.BeforeIgnoreConditions, err = toConditions(r.BeforeIgnoreConditions, n, "before_ignore_conditions"); err != nil {
+			return nil, err
+		}
+		if rule.AfterIgnoreConditions, err = toConditions(r.AfterIgnoreConditions, n, "after_ignore_conditions"); err != nil {
+			return nil, err
+		}
+		rule.EnableWhenSentenceSeparation = r.EnableWhenSentenceSeparation
+		rule.AppendLongNote = r.AppendLongNote
+		rule.DisablePrefix = r.DisablePrefix
+		rule.EnableKutenToExclamation = r.EnableKutenToExclamation
+		rule.Value = r.Value
+		if rule.Category, err = toCategory(r.Category, n); err != nil {
+			return nil, err
+		}
+		rule.ID = r.ID
+		rule.Description = r.Description
+		rule.Examples = toExamples(r.Examples)
+		rs.ConvertRules = append(rs.ConvertRules, rule)
+	}
+
+	for i, r := range rf.ContinuousRules {
+		n := root.get("continuous_rules").index(i)
+		conds, err := toConditions(r.Conditions, n, "conditions")
+		if err != nil {
+			return nil, err
+		}
+		cat, err := toCategory(r.Category, n)
+		if err != nil {
+			return nil, err
+		}
+		rs.ContinuousRules = append(rs.ContinuousRules, ContinuousRule{
+			Conditions:     conds,
+			AppendLongNote: r.AppendLongNote,
+			Value:          r.Value,
+			Category:       cat,
+			ID:             r.ID,
+			Description:    r.Description,
+			Examples:       toExamples(r.Examples),
+		})
+	}
+
+	for i, r := range rf.SentenceEndingRules {
+		n := root.get("sentence_ending_rules").index(i)
+		rule, err := r.toSentenceEndingRule(n)
+		if err != nil {
+			return nil, err
+		}
+		rs.SentenceEndingRules = append(rs.SentenceEndingRules, rule)
+	}
+
+	for i, r := range rf.ExcludeRules {
+		n := root.get("exclude_rules").index(i)
+		conds, err := toConditions(r.Conditions, n, "conditions")
+		if err != nil {
+			return nil, err
+		}
+		rs.ExcludeRules = append(rs.ExcludeRules, ExcludeRule{
+			Conditions:  conds,
+			ID:          r.ID,
+			Description: r.Description,
+			Examples:    toExamples(r.Examples),
+		})
+	}
+
+	return &rs, nil
+}
+
+func (r *ruleFileSentenceEndingRule) toSentenceEndingRule(n ruleFileNode) (SentenceEndingRule, error) {
+	var rule SentenceEndingRule
+	var err error
+	if rule.Conditions1, err = toConditions(r.Conditions1, n, "conditions1"); err != nil {
+		return rule, err
+	}
+	if rule.Conditions2, err = toConditions(r.Conditions2, n, "conditions2"); err != nil {
+		return rule, err
+	}
+	if rule.AuxiliaryVerb, err = toConditions(r.AuxiliaryVerb, n, "auxiliary_verb"); err != nil {
+		return rule, err
+	}
+	if rule.Category, err = toCategory(r.Category, n); err != nil {
+		return rule, err
+	}
+	rule.ID = r.ID
+	rule.Description = r.Description
+	rule.Examples = toExamples(r.Examples)
+
+	if len(r.SentenceEndingParticle) < 1 {
+		return rule, &RuleFileError{Line: n.line(), Err: errEmptyConditions}
+	}
+	particles := n.get("sentence_ending_particle")
+	rule.SentenceEndingParticle = make(map[MeaningType][]Condition, len(r.SentenceEndingParticle))
+	for k, v := range r.SentenceEndingParticle {
+		mt := MeaningType(k)
+		if err := mt.validate(); err != nil {
+			return rule, &RuleFileError{Line: particles.key(k).line(), Err: err}
+		}
+		conds, err := toConditions(v, particles, k)
+		if err != nil {
+			return rule, err
+		}
+		rule.SentenceEndingParticle[mt] = conds
+	}
+
+	if len(r.Priority) > 0 {
+		priorities := n.get("priority")
+		rule.Priority = make(map[MeaningType]int, len(r.Priority))
+		for k, v := range r.Priority {
+			mt := MeaningType(k)
+			if err := mt.validate(); err != nil {
+				return rule, &RuleFileError{Line: priorities.key(k).line(), Err: err}
+			}
+			rule.Priority[mt] = v
+		}
+	}
+	if a, b, ok := rule.toInternal().SentenceEndingParticle.FindAmbiguousMeaningTypes(); ok {
+		return rule, &RuleFileError{Line: particles.line(), Err: fmt.Errorf("%w: %s and %s", errAmbiguousMeaningType, a, b)}
+	}
+
+	values := n.get("value")
+	rule.Value = make(map[MeaningType][]string, len(r.Value))
+	for k, v := range r.Value {
+		mt := MeaningType(k)
+		if err := mt.validate(); err != nil {
+			return rule, &RuleFileError{Line: values.key(k).line(), Err: err}
+		}
+		rule.Value[mt] = v
+	}
+
+	if len(r.ValueWeights) > 0 {
+		weights := n.get("value_weights")
+		rule.ValueWeights = make(map[MeaningType][]int, len(r.ValueWeights))
+		for k, v := range r.ValueWeights {
+			mt := MeaningType(k)
+			if err := mt.validate(); err != nil {
+				return rule, &RuleFileError{Line: weights.key(k).line(), Err: err}
+			}
+			if err := validateValueWeights(v, len(rule.Value[mt])); err != nil {
+				return rule, &RuleFileError{Line: weights.key(k).line(), Err: err}
+			}
+			rule.ValueWeights[mt] = v
+		}
+	}
+
+	return rule, nil
+}
+
+// toConditions は変換ルールファイルの条件を変換条件に変換する。
+//
+// 補助的な条件以外は空であってはならない。
+func toConditions(rfc []ruleFileCondition, rule ruleFileNode, key string) ([]Condition, error) {
+	if len(rfc) < 1 && !isOptionalConditionsKey(key) {
+		return nil, &RuleFileError{Line: rule.line(), Err: errEmptyConditions}
+	}
+
+	var conds []Condition
+	for i, c := range rfc {
+		n := rule.get(key).index(i)
+		cond := Condition{
+			Features: c.Features,
+			Surface:  c.Surface,
+			Reading:  c.Reading,
+			BaseForm: c.BaseForm,
+		}
+		var err error
+		if cond.SurfaceRe, err = compileRuleFileRegexp(c.SurfaceRe, n, "surface_re"); err != nil {
+			return nil, err
+		}
+		if cond.ReadingRe, err = compileRuleFileRegexp(c.ReadingRe, n, "reading_re"); err != nil {
+			return nil, err
+		}
+		if cond.BaseFormRe, err = compileRuleFileRegexp(c.BaseFormRe, n, "base_form_re"); err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+	}
+	return conds, nil
+}
+
+// toExamples は変換ルールファイルの変換例を変換ルールの変換例に変換する。
+func toExamples(rfe []ruleFileExample) []Example {
+	if rfe == nil {
+		return nil
+	}
+	result := make([]Example, 0, len(rfe))
+	for _, e := range rfe {
+		result = append(result, Example{Input: e.Input, Want: e.Want})
+	}
+	return result
+}
+
+// toCategory は変換ルールファイルの分類を変換ルールの分類に変換する。
+func toCategory(s string, rule ruleFileNode) (Category, error) {
+	cat := Category(s)
+	if err := cat.validate(); err != nil {
+		return "", &RuleFileError{Line: rule.get("category").line(), Err: err}
+	}
+	return cat, nil
+}
+
+func isOptionalConditionsKey(key string) bool {
+	switch key {
+	case "before_ignore_conditions", "after_ignore_conditions", "auxiliary_verb":
+		return true
+	}
+	return false
+}
+
+func compileRuleFileRegexp(s string, cond ruleFileNode, key string) (*regexp.Regexp, error) {
+	if s == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return nil, &RuleFileError{Line: cond.get(key).line(), Err: err}
+	}
+	return re, nil
+}
+
+// ruleFileNode は変換ルールファイル中の要素の位置を特定するためのノード。
+type ruleFileNode struct {
+	node *yaml.Node
+}
+
+func newRuleFileNode(root *yaml.Node) ruleFileNode {
+	n := root
+	if n.Kind == yaml.DocumentNode && 0 < len(n.Content) {
+		n = n.Content[0]
+	}
+	return ruleFileNode{node: n}
+}
+
+// get はマッピングの key の値のノードを返す。
+func (n ruleFileNode) get(key string) ruleFileNode {
+	if n.node == nil || n.node.Kind != yaml.MappingNode {
+		return n
+	}
+	for i := 0; i+1 < len(n.node.Content); i += 2 {
+		if n.node.Content[i].Value == key {
+			return ruleFileNode{node: n.node.Content[i+1]}
+		}
+	}
+	return n
+}
+
+// key はマッピングの key そのもののノードを返す。
+func (n ruleFileNode) key(key string) ruleFileNode {
+	if n.node == nil || n.node.Kind != yaml.MappingNode {
+		return n
+	}
+	for i := 0; i+1 < len(n.node.Content); i += 2 {
+		if n.node.Content[i].Value == key {
+			return ruleFileNode{node: n.node.Content[i]}
+		}
+	}
+	return n
+}
+
+// index はシーケンスの i 番目の要素のノードを返す。
+func (n ruleFileNode) index(i int) ruleFileNode {
+	if n.node == nil || n.node.Kind != yaml.SequenceNode || len(n.node.Content) <= i {
+		return n
+	}
+	return ruleFileNode{node: n.node.Content[i]}
+}
+
+// line はノードの行番号を返す。
+func (n ruleFileNode) line() int {
+	if n.node == nil {
+		return 0
+	}
+	return n.node.Line
+}
+
+// MarshalRuleFile は変換ルールを format 形式の変換ルールファイルの内容に変換する。
+//
+// 出力した内容は ParseRuleFile で同じ変換ルールとして読み込める。
+func MarshalRuleFile(rs *RuleSet, format RuleFileFormat) ([]byte, error) {
+	rf := newRuleFile(rs)
+
+	var buf bytes.Buffer
+	switch format {
+	case RuleFileFormatJSON:
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rf); err != nil {
+			return nil, err
+		}
+	default:
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(rf); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func newRuleFile(rs *RuleSet) *ruleFile {
+	var rf ruleFile
+	if rs == nil {
+		return &rf
+	}
+
+	for _, r := range rs.ConvertRules {
+		rf.ConvertRules = append(rf.ConvertRules, ruleFileRule{
+			Conditions:                   newRuleFileConditions(r.Conditions),
+			BeforeIgnoreConditions:       newRuleFileConditions(r.BeforeIgnoreConditions),
+			AfterIgnoreConditions:        newRuleFileConditions(r.AfterIgnoreConditions),
+			EnableWhenSentenceSeparation: r.EnableWhenSentenceSeparation,
+			AppendLongNote:               r.AppendLongNote,
+			DisablePrefix:                r.DisablePrefix,
+			EnableKutenToExclamation:     r.EnableKutenToExclamation,
+			Value:                        r.Value,
+			Category:                     string(r.Category),
+			ID:                           r.ID,
+			Description:                  r.Description,
+			Examples:                     newRuleFileExamples(r.Examples),
+		})
+	}
+
+	for _, r := range rs.ContinuousRules {
+		rf.ContinuousRules = append(rf.ContinuousRules, ruleFileContinuousRule{
+			Conditions:     newRuleFileConditions(r.Conditions),
+			AppendLongNote: r.AppendLongNote,
+			Value:          r.Value,
+			Category:       string(r.Category),
+			ID:             r.ID,
+			Description:    r.Description,
+			Examples:       newRuleFileExamples(r.Examples),
+		})
+	}
+
+	for _, r := range rs.SentenceEndingRules {
+		rule := ruleFileSentenceEndingRule{
+			Conditions1:            newRuleFileConditions(r.Conditions1),
+			Conditions2:            newRuleFileConditions(r.Conditions2),
+			AuxiliaryVerb:          newRuleFileConditions(r.AuxiliaryVerb),
+			SentenceEndingParticle: make(map[string][]ruleFileCondition, len(r.SentenceEndingParticle)),
+			Value:                  make(map[string][]string, len(r.Value)),
+			Category:               string(r.Category),
+			ID:                     r.ID,
+			Description:            r.Description,
+			Examples:               newRuleFileExamples(r.Examples),
+		}
+		for k, v := range r.SentenceEndingParticle {
+			rule.SentenceEndingParticle[string(k)] = newRuleFileConditions(v)
+		}
+		if len(r.Priority) > 0 {
+			rule.Priority = make(map[string]int, len(r.Priority))
+			for k, v := range r.Priority {
+				rule.Priority[string(k)] = v
+			}
+		}
+		for k, v := range r.Value {
+			rule.Value[string(k)] = v
+		}
+		if len(r.ValueWeights) > 0 {
+			rule.ValueWeights = make(map[string][]int, len(r.ValueWeights))
+			for k, v := range r.ValueWeights {
+				rule.ValueWeights[string(k)] = v
+			}
+		}
+		rf.SentenceEndingRules = append(rf.SentenceEndingRules, rule)
+	}
+
+	for _, r := range rs.ExcludeRules {
+		rf.ExcludeRules = append(rf.ExcludeRules, ruleFileExcludeRule{
+			Conditions:  newRuleFileConditions(r.Conditions),
+			ID:          r.ID,
+			Description: r.Description,
+			Examples:    newRuleFileExamples(r.Examples),
+		})
+	}
+
+	return &rf
+}
+
+func newRuleFileConditions(conds []Condition) []ruleFileCondition {
+	var result []ruleFileCondition
+	for _, c := range conds {
+		result = append(result, ruleFileCondition{
+			Features:   c.Features,
+			Surface:    c.Surface,
+			SurfaceRe:  regexpString(c.SurfaceRe),
+			Reading:    c.Reading,
+			ReadingRe:  regexpString(c.ReadingRe),
+			BaseForm:   c.BaseForm,
+			BaseFormRe: regexpString(c.BaseFormRe),
+		})
+	}
+	return result
+}
+
+func newRuleFileExamples(examples []Example) []ruleFileExample {
+	var result []ruleFileExample
+	for _, e := range examples {
+		result = append(result, ruleFileExample{Input: e.Input, Want: e.Want})
+	}
+	return result
+}
+
+func regexpString(re *regexp.Regexp) string {
+	if re == nil {
+		return ""
+	}
+	return re.String()
+}
@@ -0,0 +1,417 @@
+package ojosama
+
+import (
+	"testing"
+
+	"github.com/jiro4989/ojosama/internal/converter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadRuleFile(t *testing.T) {
+	tests := []struct {
+		desc    string
+		path    string
+		src     string
+		want    string
+		wantErr bool
+	}{
+		{
+			desc:    "正常系: YAML のルールファイルを読み込めますわ",
+			path:    "testdata/rules/sample.yaml",
+			src:     "俺はハーブです",
+			want:    "ワタクシは薬草ですわ",
+			wantErr: false,
+		},
+		{
+			desc:    "正常系: JSON のルールファイルを読み込めますわ",
+			path:    "testdata/rules/sample.json",
+			src:     "俺はハーブです",
+			want:    "ワタクシは薬草ですわ",
+			wantErr: false,
+		},
+		{
+			desc:    "正常系: 連続する条件のルールも読み込めますわ",
+			path:    "testdata/rules/sample.yaml",
+			src:     "田中さんです",
+			want:    "田中様ですわ",
+			wantErr: false,
+		},
+		{
+			desc:    "異常系: 存在しないファイルはエラーですわ",
+			path:    "testdata/rules/not_found.yaml",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rs, err := LoadRuleFile(tt.path)
+			if tt.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+
+			got, err := Convert(tt.src, &ConvertOption{
+				DisableKutenToExclamation: true,
+				PrependRules:              rs,
+			})
+			assert.NoError(err)
+			assert.Equal(tt.want, got)
+		})
+	}
+}
+
+func TestParseRuleFile(t *testing.T) {
+	tests := []struct {
+		desc     string
+		src      string
+		format   RuleFileFormat
+		want     *RuleSet
+		wantLine int
+		wantErr  bool
+	}{
+		{
+			desc:    "正常系: 空のファイルは空のルールですわ",
+			src:     "",
+			format:  RuleFileFormatYAML,
+			want:    &RuleSet{},
+			wantErr: false,
+		},
+		{
+			desc: "正常系: 前後の無視する条件も読み込めますわ",
+			src: `convert_rules:
+  - conditions:
+      - surface: 俺
+    before_ignore_conditions:
+      - surface: お
+    after_ignore_conditions:
+      - reading: サマ
+    enable_when_sentence_separation: true
+    append_long_note: true
+    enable_kuten_to_exclamation: true
+    value: ワタクシ
+`,
+			format: RuleFileFormatYAML,
+			want: &RuleSet{
+				ConvertRules: []Rule{
+					{
+						Conditions:                   []Condition{{Surface: "俺"}},
+						BeforeIgnoreConditions:       []Condition{{Surface: "お"}},
+						AfterIgnoreConditions:        []Condition{{Reading: "サマ"}},
+						EnableWhenSentenceSeparation: true,
+						AppendLongNote:               true,
+						EnableKutenToExclamation:     true,
+						Value:                        "ワタクシ",
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			desc: "異常系: 条件が空のルールは行番号付きのエラーですわ",
+			src: `convert_rules:
+  - conditions:
+      - surface: 俺
+    value: ワタクシ
+  - conditions: []
+    value: ワタクシ
+`,
+			format:   RuleFileFormatYAML,
+			wantLine: 5,
+			wantErr:  true,
+		},
+		{
+			desc: "異常系: 不正な正規表現は行番号付きのエラーですわ",
+			src: `exclude_rules:
+  - conditions:
+      - surface: 俺
+        surface_re: "[a-"
+`,
+			format:   RuleFileFormatYAML,
+			wantLine: 4,
+			wantErr:  true,
+		},
+		{
+			desc: "異常系: 未知のキーは行番号付きのエラーですわ",
+			src: `convert_rules:
+  - conditions:
+      - surfase: 俺
+    value: ワタクシ
+`,
+			format:  RuleFileFormatYAML,
+			wantErr: true,
+		},
+		{
+			desc: "正常系: 文末の変換ルールも読み込めますわ",
+			src: `sentence_ending_rules:
+  - conditions1:
+      - features: [名詞, 一般]
+    conditions2:
+      - base_form: する
+    sentence_ending_particle:
+      hope:
+        - surface: よ
+    value:
+      hope: [をいたしませんこと]
+`,
+			format: RuleFileFormatYAML,
+			want: &RuleSet{
+				SentenceEndingRules: []SentenceEndingRule{
+					{
+						Conditions1: []Condition{{Features: []string{"名詞", "一般"}}},
+						Conditions2: []Condition{{BaseForm: "する"}},
+						SentenceEndingParticle: map[MeaningType][]Condition{
+							MeaningTypeHope: {{Surface: "よ"}},
+						},
+						Value: map[MeaningType][]string{
+							MeaningTypeHope: {"をいたしませんこと"},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			desc: "正常系: 文末の変換候補の重みも読み込めますわ",
+			src: `sentence_ending_rules:
+  - conditions1:
+      - features: [名詞, 一般]
+    conditions2:
+      - base_form: する
+    sentence_ending_particle:
+      hope:
+        - surface: よ
+    value:
+      hope: [をいたしませんこと, をいたしましょう]
+    value_weights:
+      hope: [3, 1]
+`,
+			format: RuleFileFormatYAML,
+			want: &RuleSet{
+				SentenceEndingRules: []SentenceEndingRule{
+					{
+						Conditions1: []Condition{{Features: []string{"名詞", "一般"}}},
+						Conditions2: []Condition{{BaseForm: "する"}},
+						SentenceEndingParticle: map[MeaningType][]Condition{
+							MeaningTypeHope: {{Surface: "よ"}},
+						},
+						Value: map[MeaningType][]string{
+							MeaningTypeHope: {"をいたしませんこと", "をいたしましょう"},
+						},
+						ValueWeights: map[MeaningType][]int{
+							MeaningTypeHope: {3, 1},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			desc: "異常系: 変換候補と重みの数が違う場合は行番号付きのエラーですわ",
+			src: `sentence_ending_rules:
+  - conditions1:
+      - features: [名詞, 一般]
+    conditions2:
+      - base_form: する
+    sentence_ending_particle:
+      hope:
+        - surface: よ
+    value:
+      hope: [をいたしませんこと, をいたしましょう]
+    value_weights:
+      hope: [1]
+`,
+			format:   RuleFileFormatYAML,
+			wantLine: 12,
+			wantErr:  true,
+		},
+		{
+			desc: "正常系: 意味分類の優先度も読み込めますわ",
+			src: `sentence_ending_rules:
+  - conditions1:
+      - features: [名詞, 一般]
+    conditions2:
+      - base_form: する
+    sentence_ending_particle:
+      hope:
+        - surface: よ
+      coercion:
+        - surface: よ
+    priority:
+      coercion: 1
+    value:
+      hope: [をいたしませんこと]
+      coercion: [をいたしますわよ]
+`,
+			format: RuleFileFormatYAML,
+			want: &RuleSet{
+				SentenceEndingRules: []SentenceEndingRule{
+					{
+						Conditions1: []Condition{{Features: []string{"名詞", "一般"}}},
+						Conditions2: []Condition{{BaseForm: "する"}},
+						SentenceEndingParticle: map[MeaningType][]Condition{
+							MeaningTypeHope:     {{Surface: "よ"}},
+							MeaningTypeCoercion: {{Surface: "よ"}},
+						},
+						Priority: map[MeaningType]int{MeaningTypeCoercion: 1},
+						Value: map[MeaningType][]string{
+							MeaningTypeHope:     {"をいたしませんこと"},
+							MeaningTypeCoercion: {"をいたしますわよ"},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			desc: "異常系: 優先度の無い曖昧な終助詞の条件は行番号付きのエラーですわ",
+			src: `sentence_ending_rules:
+  - conditions1:
+      - features: [名詞, 一般]
+    conditions2:
+      - base_form: する
+    sentence_ending_particle:
+      hope:
+        - surface: よ
+      coercion:
+        - surface: よ
+    value:
+      hope: [をいたしませんこと]
+`,
+			format:   RuleFileFormatYAML,
+			wantLine: 7,
+			wantErr:  true,
+		},
+		{
+			desc: "異常系: 不明な意味分類は行番号付きのエラーですわ",
+			src: `sentence_ending_rules:
+  - conditions1:
+      - features: [名詞, 一般]
+    conditions2:
+      - base_form: する
+    sentence_ending_particle:
+      hope:
+        - surface: よ
+    value:
+      hopo: [をいたしませんこと]
+`,
+			format:   RuleFileFormatYAML,
+			wantLine: 10,
+			wantErr:  true,
+		},
+		{
+			desc: "正常系: 分類には adjective と exclusion も指定できますわ",
+			src: `convert_rules:
+  - conditions:
+      - surface: 美しい
+    value: 麗しい
+    category: adjective
+  - conditions:
+      - surface: ハーブ
+    value: ハーブ
+    category: exclusion
+`,
+			format: RuleFileFormatYAML,
+			want: &RuleSet{
+				ConvertRules: []Rule{
+					{
+						Conditions: []Condition{{Surface: "美しい"}},
+						Value:      "麗しい",
+						Category:   CategoryAdjective,
+					},
+					{
+						Conditions: []Condition{{Surface: "ハーブ"}},
+						Value:      "ハーブ",
+						Category:   CategoryExclusion,
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			desc: "異常系: 不明な分類は行番号付きのエラーですわ",
+			src: `convert_rules:
+  - conditions:
+      - surface: 俺
+    value: ワタクシ
+    category: hoge
+`,
+			format:   RuleFileFormatYAML,
+			wantLine: 5,
+			wantErr:  true,
+		},
+		{
+			desc: "異常系: 不正な JSON は行番号付きのエラーですわ",
+			src: `{
+  "convert_rules": [
+    {"conditions": [{"surface": "俺"}], "value": "ワタクシ",}
+  ]
+}`,
+			format:   RuleFileFormatJSON,
+			wantLine: 3,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := ParseRuleFile([]byte(tt.src), tt.format)
+			if tt.wantErr {
+				assert.Error(err)
+				if 0 < tt.wantLine {
+					var rfe *RuleFileError
+					if assert.ErrorAs(err, &rfe) {
+						assert.Equal(tt.wantLine, rfe.Line)
+					}
+				}
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tt.want, got)
+		})
+	}
+}
+
+func TestMarshalRuleFile(t *testing.T) {
+	tests := []struct {
+		desc   string
+		format RuleFileFormat
+	}{
+		{
+			desc:   "正常系: 組み込みの変換ルールを YAML で書き出して読み戻せますわ",
+			format: RuleFileFormatYAML,
+		},
+		{
+			desc:   "正常系: 組み込みの変換ルールを JSON で書き出して読み戻せますわ",
+			format: RuleFileFormatJSON,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			b, err := MarshalRuleFile(BuiltinRules(), tt.format)
+			assert.NoError(err)
+
+			got, err := ParseRuleFile(b, tt.format)
+			assert.NoError(err)
+
+			// 変換エンジンが使う形式に戻した時に、組み込みの変換ルールと一致すること
+			assert.Equal(converter.ConvertRules, got.convertRules())
+			assert.Equal(converter.ContinuousConditionsConvertRules, got.continuousRules())
+			assert.Equal(converter.SentenceEndingParticleConvertRules, got.sentenceEndingRules())
+			assert.Equal(converter.ExcludeRules, got.excludeRules())
+
+			// 再度書き出しても同じ内容になること
+			b2, err := MarshalRuleFile(got, tt.format)
+			assert.NoError(err)
+			assert.Equal(string(b), string(b2))
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package ojosama
+
+import (
+	"context"
+	"unicode/utf8"
+)
+
+// Segment は変換元のテキストの一部と、その部分の変換結果の対応関係。
+//
+// オフセットはいずれも開始位置を含み、終了位置を含まない。
+// バイト単位のオフセットは文字列のスライスに、
+// 文字単位のオフセットはエディタ上の位置の計算などに使う。
+type Segment struct {
+	Source          string // 変換元のテキストの一部
+	SourceStart     int    // 変換元のテキスト中の開始位置（バイト単位）
+	SourceEnd       int    // 変換元のテキスト中の終了位置（バイト単位）
+	SourceRuneStart int    // 変換元のテキスト中の開始位置（文字単位）
+	SourceRuneEnd   int    // 変換元のテキスト中の終了位置（文字単位）
+
+	Result          string // 変換後のテキストの一部
+	ResultStart     int    // 変換後のテキスト中の開始位置（バイト単位）
+	ResultEnd       int    // 変換後のテキスト中の終了位置（バイト単位）
+	ResultRuneStart int    // 変換後のテキスト中の開始位置（文字単位）
+	ResultRuneEnd   int    // 変換後のテキスト中の終了位置（文字単位）
+}
+
+// Converted は変換によってテキストが変化したかどうかを返す。
+func (s Segment) Converted() bool {
+	return s.Source != s.Result
+}
+
+// ConvertSegments は Convert と同じ変換を行い、
+// 変換結果を変換元のテキストとの対応関係のリストとして返す。
+//
+// すべての Segment の Result を連結すると Convert の変換結果になり、
+// すべての Segment の Source を連結すると src になる。
+func ConvertSegments(src string, opt *ConvertOption) ([]Segment, error) {
+	c, err := converterFor(opt)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.validateDisableRules(opt); err != nil {
+		return nil, err
+	}
+	return c.convertSegments(src, opt)
+}
+
+// ConvertSegments は Convert と同じ変換を行い、
+// 変換結果を変換元のテキストとの対応関係のリストとして返す。
+func (c *Converter) ConvertSegments(src string) ([]Segment, error) {
+	return c.convertSegments(src, c.opt)
+}
+
+func (c *Converter) convertSegments(src string, opt *ConvertOption) ([]Segment, error) {
+	tokens, spans, err := c.convertSpans(context.Background(), src, opt)
+	if err != nil {
+		return nil, err
+	}
+	segs := make([]Segment, 0, len(spans))
+	var resultPos, resultRunePos int
+	for _, sp := range spans {
+		first := tokens[sp.start]
+		last := tokens[sp.end-1]
+		result := sp.String()
+		resultRuneLen := utf8.RuneCountInString(result)
+
+		seg := Segment{
+			Source:          src[first.Position : last.Position+len(last.Surface)],
+			SourceStart:     first.Position,
+			SourceEnd:       last.Position + len(last.Surface),
+			SourceRuneStart: first.Start,
+			SourceRuneEnd:   last.End,
+			Result:          result,
+			ResultStart:     resultPos,
+			ResultEnd:       resultPos + len(result),
+			ResultRuneStart: resultRunePos,
+			ResultRuneEnd:   resultRunePos + resultRuneLen,
+		}
+		segs = append(segs, seg)
+
+		resultPos += len(result)
+		resultRunePos += resultRuneLen
+	}
+	return segs, nil
+}
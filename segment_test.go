@@ -0,0 +1,80 @@
+package ojosama
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertSegments(t *testing.T) {
+	assert := assert.New(t)
+
+	opt := &ConvertOption{
+		DisableKutenToExclamation: true,
+	}
+	got, err := ConvertSegments("これはgrassです", opt)
+	assert.NoError(err)
+
+	want := []Segment{
+		{
+			Source: "これ", SourceStart: 0, SourceEnd: 6, SourceRuneStart: 0, SourceRuneEnd: 2,
+			Result: "こちら", ResultStart: 0, ResultEnd: 9, ResultRuneStart: 0, ResultRuneEnd: 3,
+		},
+		{
+			Source: "は", SourceStart: 6, SourceEnd: 9, SourceRuneStart: 2, SourceRuneEnd: 3,
+			Result: "は", ResultStart: 9, ResultEnd: 12, ResultRuneStart: 3, ResultRuneEnd: 4,
+		},
+		{
+			Source: "grass", SourceStart: 9, SourceEnd: 14, SourceRuneStart: 3, SourceRuneEnd: 8,
+			Result: "grass", ResultStart: 12, ResultEnd: 17, ResultRuneStart: 4, ResultRuneEnd: 9,
+		},
+		{
+			Source: "です", SourceStart: 14, SourceEnd: 20, SourceRuneStart: 8, SourceRuneEnd: 10,
+			Result: "ですわ", ResultStart: 17, ResultEnd: 26, ResultRuneStart: 9, ResultRuneEnd: 12,
+		},
+	}
+	assert.Equal(want, got)
+	assert.True(got[0].Converted())
+	assert.False(got[1].Converted())
+}
+
+func TestConvertSegmentsUnknownRuleID(t *testing.T) {
+	assert := assert.New(t)
+
+	// Convert と同じく存在しないIDはエラーですわ
+	got, err := ConvertSegments("これは何か", &ConvertOption{DisableRules: []string{"ending.存在しない"}})
+	assert.ErrorIs(err, errUnknownRuleID)
+	assert.Empty(got)
+}
+
+func TestConvertSegmentsOffsets(t *testing.T) {
+	assert := assert.New(t)
+
+	b, err := os.ReadFile("testdata/sample2.txt")
+	assert.NoError(err)
+	src := string(b)
+
+	seed := int64(1)
+	opt := &ConvertOption{Seed: &seed}
+	want, err := Convert(src, opt)
+	assert.NoError(err)
+
+	segs, err := ConvertSegments(src, opt)
+	assert.NoError(err)
+
+	// Source と Result を連結するとそれぞれ変換前と変換後のテキストになりますわ
+	var source, result strings.Builder
+	for _, seg := range segs {
+		source.WriteString(seg.Source)
+		result.WriteString(seg.Result)
+
+		assert.Equal(seg.Source, src[seg.SourceStart:seg.SourceEnd])
+		assert.Equal(seg.Result, want[seg.ResultStart:seg.ResultEnd])
+		assert.Equal(seg.Source, string([]rune(src)[seg.SourceRuneStart:seg.SourceRuneEnd]))
+		assert.Equal(seg.Result, string([]rune(want)[seg.ResultRuneStart:seg.ResultRuneEnd]))
+	}
+	assert.Equal(src, source.String())
+	assert.Equal(want, result.String())
+}
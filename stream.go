@@ -0,0 +1,204 @@
+package ojosama
+
+import (
+	"context"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+)
+
+// maxStreamChunkSize は逐次変換で1度に変換する最大のバイト数。
+//
+// transform.Reader や transform.Writer の内部のバッファと同じ大きさにしている。
+// この大きさまでに文の区切りが無い場合は、読点や空白、文字の区切りで分割して変換する。
+const maxStreamChunkSize = 4096
+
+// NewTransformer は opt の設定でお嬢様言葉に逐次変換する transform.Transformer を返す。
+//
+// opt の扱いは Convert と同じ。
+func NewTransformer(opt *ConvertOption) (transform.Transformer, error) {
+	c, err := converterFor(opt)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.validateDisableRules(opt); err != nil {
+		return nil, err
+	}
+	return c.newTransformer(opt), nil
+}
+
+// NewTransformer はお嬢様言葉に逐次変換する transform.Transformer を返す。
+//
+// 入力を文の区切り（句点、感嘆符、疑問符、改行）までためてから変換して出力するため、
+// 入力全体をメモリに載せずに大きなファイルを変換できる。
+// transform.Chain で文字コードの変換と組み合わせたり、
+// transform.NewReader や transform.NewWriter で io.Reader や io.Writer として使える。
+//
+// 乱数と直近に使った語尾は文をまたいで引き継ぐ。
+// ただし文の区切りの前後の単語を見る変換があるため、乱数を使う変換は
+// Convert で文章全体を変換した場合と結果が異なることがある。
+// また、1つの文が maxStreamChunkSize を超える場合は文の途中で分割して変換する。
+//
+// opt の MaxInputSize を指定した場合は、読み込んだテキストの合計が超えた時点で
+// InputTooLargeError を返す。
+// 変換できない文字を見つけた場合は、入力の先頭からの位置を含めた InvalidInputError を返す。
+//
+// 返却する transform.Transformer は状態を持つため、複数のゴルーチンから同時に使ってはいけない。
+func (c *Converter) NewTransformer() transform.Transformer {
+	return c.newTransformer(c.opt)
+}
+
+// NewReader は r から読み込んだテキストをお嬢様言葉に逐次変換して読み込む io.Reader を返す。
+func (c *Converter) NewReader(r io.Reader) io.Reader {
+	return transform.NewReader(r, c.NewTransformer())
+}
+
+// NewWriter は書き込んだテキストをお嬢様言葉に逐次変換して w に書き込む io.WriteCloser を返す。
+//
+// 文の区切りまで書き込まれていないテキストは Close で変換して書き込むため、
+// 書き込みが終わったら必ず Close を呼ぶこと。 Close は w を閉じない。
+func (c *Converter) NewWriter(w io.Writer) io.WriteCloser {
+	return transform.NewWriter(w, c.NewTransformer())
+}
+
+func (c *Converter) newTransformer(opt *ConvertOption) *streamTransformer {
+	return &streamTransformer{
+		c:   c,
+		opt: opt,
+		st:  newConvertState(opt),
+	}
+}
+
+// streamTransformer は文の区切りごとにお嬢様言葉に変換する transform.Transformer 。
+type streamTransformer struct {
+	c   *Converter
+	opt *ConvertOption
+	st  *convertState
+
+	pending []byte // 変換済みで、まだ dst に書き出せていないテキスト
+	read    int    // これまでに変換したテキストの大きさ（バイト単位）
+}
+
+// Reset は変換の途中の状態を破棄して、最初から変換できるようにする。
+func (t *streamTransformer) Reset() {
+	t.pending = nil
+	t.read = 0
+	t.st = newConvertState(t.opt)
+}
+
+// Transform は src を文の区切りまで変換して dst に書き出す。
+//
+// 文の区切りが見つからない場合は、続きの入力を待つために transform.ErrShortSrc を返す。
+// 変換結果が dst に収まらない場合は残りを保持して transform.ErrShortDst を返し、
+// 次の呼び出しで先に書き出す。
+func (t *streamTransformer) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for {
+		n := copy(dst[nDst:], t.pending)
+		nDst += n
+		t.pending = t.pending[n:]
+		if 0 < len(t.pending) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		if len(src) <= nSrc {
+			return nDst, nSrc, nil
+		}
+
+		end := streamChunkEnd(src[nSrc:], atEOF)
+		if end < 1 {
+			return nDst, nSrc, transform.ErrShortSrc
+		}
+		if err := checkInputSize(t.opt, t.read+end); err != nil {
+			return nDst, nSrc, err
+		}
+		if err := validateInput(string(src[nSrc:nSrc+end]), t.read); err != nil {
+			return nDst, nSrc, err
+		}
+		s, err := t.convert(string(src[nSrc : nSrc+end]))
+		if err != nil {
+			return nDst, nSrc, err
+		}
+		t.pending = append(t.pending[:0], s...)
+		t.read += end
+		nSrc += end
+	}
+}
+
+// convert は引き継いだ状態で s を変換する。
+func (t *streamTransformer) convert(s string) (string, error) {
+	_, spans, err := t.c.convertSpansWithState(context.Background(), s, t.opt, t.st)
+	if err != nil {
+		return "", err
+	}
+	var result strings.Builder
+	for _, sp := range spans {
+		result.WriteString(sp.String())
+	}
+	return result.String(), nil
+}
+
+// streamChunkEnd は b の先頭から、まとめて変換できる位置を返す。
+//
+// maxStreamChunkSize までで最後の文の区切りの位置を返す。
+// 感嘆符や閉じ括弧が続く場合は、続いているものすべてを含めた位置を文の区切りとする。
+// 文の区切りが無く、 b が maxStreamChunkSize より短い場合は続きの入力を待つために 0 を返す。
+// atEOF が true の場合は b 全体を変換する。
+func streamChunkEnd(b []byte, atEOF bool) int {
+	if atEOF {
+		return len(b)
+	}
+
+	var end, fallback, pos int
+	inMarks := false
+	for pos < len(b) {
+		if !utf8.FullRune(b[pos:]) {
+			// 途中で切れている文字は続きの入力を待つ
+			break
+		}
+		r, size := utf8.DecodeRune(b[pos:])
+		if maxStreamChunkSize < pos+size {
+			break
+		}
+
+		switch {
+		case r == '\n':
+			end = pos + size
+			inMarks = false
+		case isStreamSentenceMark(r):
+			inMarks = true
+		case inMarks && isStreamClosingBracket(r):
+			// 「ですわ！」のように文の終わりの後ろに続く閉じ括弧は文に含める
+		default:
+			// 感嘆符などの連続が終わった位置を文の区切りとする
+			if inMarks {
+				end = pos
+				inMarks = false
+			}
+			if r == '、' || unicode.IsSpace(r) {
+				fallback = pos + size
+			}
+		}
+		pos += size
+	}
+
+	if 0 < end || len(b) < maxStreamChunkSize {
+		return end
+	}
+	// 文の区切りが無いまま大きくなった場合は、読点か空白、無ければ文字の区切りで分割する
+	if 0 < fallback {
+		return fallback
+	}
+	return pos
+}
+
+// isStreamSentenceMark は r が文の終わりを表す記号かを判定する。
+func isStreamSentenceMark(r rune) bool {
+	return strings.ContainsRune("。．！？!?‼⁉❗❓", r)
+}
+
+// isStreamClosingBracket は r が閉じ括弧かを判定する。
+func isStreamClosingBracket(r rune) bool {
+	return strings.ContainsRune("」』）)", r)
+}
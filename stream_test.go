@@ -0,0 +1,190 @@
+package ojosama
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+)
+
+// 乱数を使わない設定。逐次変換でも文章全体の変換と同じ結果になる。
+var streamTestOption = &ConvertOption{
+	DisableKutenToExclamation: true,
+	DisableLongNote:           true,
+	DisableEndingVariation:    true,
+}
+
+func TestConverterNewReader(t *testing.T) {
+	tests := []struct {
+		desc string
+		file string
+	}{
+		{
+			desc: "正常系: 短い文章は文章全体を変換した場合と同じ結果になりますわ",
+			file: "testdata/sample1.txt",
+		},
+		{
+			desc: "正常系: 長い文章も文章全体を変換した場合と同じ結果になりますわ",
+			file: "testdata/sample2.txt",
+		},
+	}
+
+	c, err := NewConverter(streamTestOption)
+	assert.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			b, err := os.ReadFile(tt.file)
+			assert.NoError(err)
+			want, err := c.Convert(string(b))
+			assert.NoError(err)
+
+			// 1バイトずつ読み込んでも文の区切りまでためてから変換いたしますわ
+			got, err := io.ReadAll(c.NewReader(iotest.OneByteReader(bytes.NewReader(b))))
+			assert.NoError(err)
+			assert.Equal(want, string(got))
+
+			got, err = io.ReadAll(c.NewReader(bytes.NewReader(b)))
+			assert.NoError(err)
+			assert.Equal(want, string(got))
+		})
+	}
+}
+
+func TestConverterNewWriter(t *testing.T) {
+	assert := assert.New(t)
+
+	c, err := NewConverter(streamTestOption)
+	assert.NoError(err)
+
+	b, err := os.ReadFile("testdata/sample2.txt")
+	assert.NoError(err)
+	want, err := c.Convert(string(b))
+	assert.NoError(err)
+
+	// 文の途中で区切って書き込んでも、Close で残りを変換いたしますわ
+	var buf bytes.Buffer
+	w := c.NewWriter(&buf)
+	for i := 0; i < len(b); i += 7 {
+		_, err := w.Write(b[i:min(i+7, len(b))])
+		assert.NoError(err)
+	}
+	assert.NoError(w.Close())
+	assert.Equal(want, buf.String())
+}
+
+func TestNewTransformer(t *testing.T) {
+	assert := assert.New(t)
+
+	b, err := os.ReadFile("testdata/sample1.txt")
+	assert.NoError(err)
+	want, err := Convert(string(b), streamTestOption)
+	assert.NoError(err)
+
+	sjis, err := os.ReadFile("testdata/sample1_sjis.txt")
+	assert.NoError(err)
+
+	// 文字コードの変換と組み合わせられますわ
+	tr, err := NewTransformer(streamTestOption)
+	assert.NoError(err)
+	got, _, err := transform.Bytes(transform.Chain(japanese.ShiftJIS.NewDecoder(), tr), sjis)
+	assert.NoError(err)
+	assert.Equal(want, string(got))
+
+	// Reset すると最初から変換し直せますわ
+	tr.Reset()
+	got, _, err = transform.Bytes(transform.Chain(japanese.ShiftJIS.NewDecoder(), tr), sjis)
+	assert.NoError(err)
+	assert.Equal(want, string(got))
+
+	_, err = NewTransformer(&ConvertOption{Level: Level(100)})
+	assert.Error(err)
+}
+
+func TestConverterNewReaderLongSentence(t *testing.T) {
+	assert := assert.New(t)
+
+	c, err := NewConverter(streamTestOption)
+	assert.NoError(err)
+
+	// 文の区切りが無い長い文は読点で分割して変換いたしますわ
+	src := strings.Repeat("ハーブ、", 2000) + "ハーブです"
+	got, err := io.ReadAll(c.NewReader(strings.NewReader(src)))
+	assert.NoError(err)
+	assert.Equal(strings.Repeat("おハーブ、", 2000)+"おハーブですわ", string(got))
+
+	// 読点も無い場合は文字の区切りで分割いたしますわ
+	src = strings.Repeat("ハーブ", 3000) + "です"
+	got, err = io.ReadAll(c.NewReader(strings.NewReader(src)))
+	assert.NoError(err)
+	assert.True(strings.HasSuffix(string(got), "ですわ"))
+}
+
+func TestStreamChunkEnd(t *testing.T) {
+	tests := []struct {
+		desc  string
+		src   string
+		atEOF bool
+		want  int
+	}{
+		{
+			desc:  "正常系: 最後の文の区切りまでですわ",
+			src:   "ハーブです。ハーブです。ハーブ",
+			atEOF: false,
+			want:  len("ハーブです。ハーブです。"),
+		},
+		{
+			desc:  "正常系: 感嘆符や閉じ括弧が続く場合はすべて含めますわ",
+			src:   "「ハーブです！！」ハーブ",
+			atEOF: false,
+			want:  len("「ハーブです！！」"),
+		},
+		{
+			desc:  "正常系: 末尾の感嘆符は続きがあるかもしれないので待ちますわ",
+			src:   "ハーブです！",
+			atEOF: false,
+			want:  0,
+		},
+		{
+			desc:  "正常系: 改行は文の区切りですわ",
+			src:   "ハーブです\nハーブ",
+			atEOF: false,
+			want:  len("ハーブです\n"),
+		},
+		{
+			desc:  "正常系: 文の途中の閉じ括弧は文の区切りではありませんわ",
+			src:   "ハーブ（笑）です",
+			atEOF: false,
+			want:  0,
+		},
+		{
+			desc:  "正常系: 入力の終わりの場合はすべてですわ",
+			src:   "ハーブです",
+			atEOF: true,
+			want:  len("ハーブです"),
+		},
+		{
+			desc:  "正常系: 文字の途中で切れている場合は続きを待ちますわ",
+			src:   "ハーブです。" + string([]byte("ハ")[:2]),
+			atEOF: false,
+			want:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got := streamChunkEnd([]byte(tt.src), tt.atEOF)
+			assert.Equal(tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package ojosama
+
+import "github.com/jiro4989/ojosama/internal/converter"
+
+// isRuleEnabled はIDが id で分類が cat の変換ルールを opt の設定で使うかどうかを返す。
+func isRuleEnabled(opt *ConvertOption, id string, cat converter.Category) bool {
+	if !isCategoryEnabled(opt, cat) {
+		return false
+	}
+	if opt == nil || id == "" {
+		return true
+	}
+	for _, v := range opt.DisableRules {
+		if v == id {
+			return false
+		}
+	}
+	return true
+}
+
+// isCategoryEnabled は分類 cat の変換ルールを opt の設定で使うかどうかを返す。
+//
+// 変換の強さで無効にした分類と、機能ごとの ON/OFF や DisableCategories で
+// 無効にした分類は使わない。
+func isCategoryEnabled(opt *ConvertOption, cat converter.Category) bool {
+	for _, c := range levelConfigOf(opt).disabledCategories {
+		if c == cat {
+			return false
+		}
+	}
+
+	if opt == nil {
+		return true
+	}
+	for _, c := range opt.DisableCategories {
+		if c.toInternal() == cat {
+			return false
+		}
+	}
+	switch cat {
+	case converter.CategoryPronoun:
+		return !opt.DisablePronoun
+	case converter.CategoryDemonstrative:
+		return !opt.DisableDemonstrative
+	case converter.CategoryEnding, converter.CategoryAdjective:
+		// 形容詞文も文末に「ですわ」を付けるため、文末表現と一緒に無効にする
+		return !opt.DisableSentenceEnding
+	case converter.CategoryVulgar:
+		return !opt.DisableVulgar
+	}
+	return true
+}
+
+// prefixModeOf は opt の設定での「お」の付与の仕方を返す。
+func prefixModeOf(opt *ConvertOption) prefixMode {
+	if opt != nil && opt.DisableHonorificPrefix {
+		return prefixModeNone
+	}
+	return levelConfigOf(opt).prefix
+}
+
+// isLongNoteEnabled は opt の設定で波線や感嘆符を付与するかどうかを返す。
+//
+// LongNote を指定した場合は、変換の強さで無効にしていても付与する。
+func isLongNoteEnabled(opt *ConvertOption) bool {
+	if opt != nil && opt.DisableLongNote {
+		return false
+	}
+	if opt != nil && opt.LongNote != nil {
+		return true
+	}
+	return !levelConfigOf(opt).disableLongNote
+}
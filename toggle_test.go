@@ -0,0 +1,117 @@
+package ojosama
+
+import (
+	"testing"
+
+	"github.com/jiro4989/ojosama/internal/chars"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertToggles(t *testing.T) {
+	tests := []struct {
+		desc string
+		src  string
+		opt  *ConvertOption
+		want string
+	}{
+		{
+			desc: "正常系: トグルを指定しなければすべて変換いたしますわ",
+			src:  "俺はこれが汚いと思うハーブです",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+			},
+			want: "私はこちらがきったねぇと思うおハーブですわ",
+		},
+		{
+			desc: "正常系: 「お」を付与しませんわ",
+			src:  "俺はハーブです",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				DisableHonorificPrefix:    true,
+			},
+			want: "私はハーブですわ",
+		},
+		{
+			desc: "正常系: 人称代名詞を変換しませんわ",
+			src:  "俺はハーブです",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				DisablePronoun:            true,
+			},
+			want: "俺はおハーブですわ",
+		},
+		{
+			desc: "正常系: こそあど言葉を変換しませんわ",
+			src:  "これはハーブです",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				DisableDemonstrative:      true,
+			},
+			want: "これはおハーブですわ",
+		},
+		{
+			desc: "正常系: 文末表現を変換しませんわ",
+			src:  "俺はハーブです",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				DisableSentenceEnding:     true,
+			},
+			want: "私はおハーブです",
+		},
+		{
+			desc: "正常系: 文末表現を変換しなくても人称代名詞は変換いたしますわ",
+			src:  "俺はハーブを勉強しようぜ",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				DisableSentenceEnding:     true,
+			},
+			want: "私はおハーブを勉強しようぜ",
+		},
+		{
+			desc: "正常系: 人称代名詞を変換しなくても文末表現は変換いたしますわ",
+			src:  "俺はハーブを勉強しようぜ",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				DisablePronoun:            true,
+				DisableEndingVariation:    true,
+			},
+			want: "俺はおハーブをお勉強をいたしませんこと",
+		},
+		{
+			desc: "正常系: 下品な言葉に変換しませんわ",
+			src:  "汚いハーブです",
+			opt: &ConvertOption{
+				DisableKutenToExclamation: true,
+				DisableVulgar:             true,
+			},
+			want: "汚いおハーブですわ",
+		},
+		{
+			desc: "正常系: 波線を付与しませんわ",
+			src:  "ハーブです！",
+			opt: &ConvertOption{
+				DisableLongNote: true,
+			},
+			want: "おハーブですわ！",
+		},
+		{
+			desc: "正常系: 波線を付与する場合の比較用ですわ",
+			src:  "ハーブです！",
+			opt: &ConvertOption{
+				LongNote:           FixedLongNote(2, 1),
+				forceCharsTestMode: &chars.TestMode{Pos: 0},
+			},
+			want: "おハーブですわ～～！",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := Convert(tt.src, tt.opt)
+			assert.NoError(err)
+			assert.Equal(tt.want, got)
+		})
+	}
+}
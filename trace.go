@@ -0,0 +1,166 @@
+package ojosama
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ikawaha/kagome/v2/tokenizer"
+)
+
+// RuleKind は変換に使われたルールの種類。
+type RuleKind int
+
+const (
+	RuleKindNone                   RuleKind = iota // どの変換ルールにもマッチしなかった
+	RuleKindAlnum                                  // 英数字のみの単語なので変換しなかった
+	RuleKindSentenceEndingParticle                 // 名詞＋動詞＋終助詞の組み合わせによる変換ルール
+	RuleKindContinuousConditions                   // 連続する条件による変換ルール
+	RuleKindExclude                                // 変換を無視するルール
+	RuleKindConvert                                // 単独のトークンに対する変換ルール
+	RuleKindOjosamaStyle                           // すでにお嬢様言葉になっているので変換しなかった
+)
+
+var ruleKindNames = map[RuleKind]string{
+	RuleKindNone:                   "none",
+	RuleKindAlnum:                  "alnum",
+	RuleKindSentenceEndingParticle: "sentence_ending_particle",
+	RuleKindContinuousConditions:   "continuous_conditions",
+	RuleKindExclude:                "exclude",
+	RuleKindConvert:                "convert",
+	RuleKindOjosamaStyle:           "ojosama_style",
+}
+
+func (k RuleKind) String() string {
+	if s, ok := ruleKindNames[k]; ok {
+		return s
+	}
+	return fmt.Sprintf("unknown(%d)", int(k))
+}
+
+// TraceSpan は変換結果の1区間について、どの変換ルールで変換されたかを表す。
+type TraceSpan struct {
+	TokenStart int          // 変換元のトークンの開始位置
+	TokenEnd   int          // 変換元のトークンの終了位置。この位置のトークンは含まない
+	Tokens     []TraceToken // 変換元のトークン
+	Text       string       // 変換後の文字列。装飾も含む
+
+	RuleKind  RuleKind // マッチした変換ルールの種類
+	RuleIndex int      // マッチした変換ルールの、種類ごとの変換ルール中の位置。ルールが無い場合は -1
+	RuleID    string   // マッチした変換ルールのID。ルールが無いか、IDが未設定の場合は空文字列
+
+	Prefix             string // 手前に付与した「お」
+	LongNote           string // ランダムに付与した波線や感嘆符
+	KutenToExclamation string // ランダムに句点を変換した文字列
+}
+
+// TraceToken は変換元のトークン。
+type TraceToken struct {
+	Surface  string   // 表層形
+	Features []string // 品詞などの素性
+}
+
+// ConvertWithTrace は Convert と同じ変換を行い、
+// 変換結果と合わせて区間ごとにどの変換ルールが使われたかを返す。
+//
+// 変換結果が意図しないものになった時の調査に使う。
+func ConvertWithTrace(src string, opt *ConvertOption) (string, []TraceSpan, error) {
+	c, err := converterFor(opt)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := c.validateDisableRules(opt); err != nil {
+		return "", nil, err
+	}
+	return c.convertWithTrace(src, opt)
+}
+
+// ConvertWithTrace は Convert と同じ変換を行い、
+// 変換結果と合わせて区間ごとにどの変換ルールが使われたかを返す。
+func (c *Converter) ConvertWithTrace(src string) (string, []TraceSpan, error) {
+	return c.convertWithTrace(src, c.opt)
+}
+
+func (c *Converter) convertWithTrace(src string, opt *ConvertOption) (string, []TraceSpan, error) {
+	tokens, spans, err := c.convertSpans(context.Background(), src, opt)
+	if err != nil {
+		return "", nil, err
+	}
+	var result strings.Builder
+	traces := make([]TraceSpan, 0, len(spans))
+	for _, sp := range spans {
+		s := sp.String()
+		result.WriteString(s)
+
+		ts := TraceSpan{
+			TokenStart:         sp.start,
+			TokenEnd:           sp.end,
+			Text:               s,
+			RuleKind:           sp.ruleKind,
+			RuleIndex:          sp.ruleIndex,
+			RuleID:             c.ruleID(sp.ruleKind, sp.ruleIndex),
+			Prefix:             sp.prefix,
+			LongNote:           sp.longNote,
+			KutenToExclamation: sp.kutenToExclamation,
+		}
+		for _, token := range tokens[sp.start:sp.end] {
+			data := tokenizer.NewTokenData(token)
+			ts.Tokens = append(ts.Tokens, TraceToken{
+				Surface:  data.Surface,
+				Features: data.Features,
+			})
+		}
+		traces = append(traces, ts)
+	}
+	return result.String(), traces, nil
+}
+
+// ruleID は kind の種類の index 番目の変換ルールのIDを返す。
+func (c *Converter) ruleID(kind RuleKind, index int) string {
+	if index < 0 {
+		return ""
+	}
+	switch kind {
+	case RuleKindSentenceEndingParticle:
+		return c.sentenceEndingParticleConvertRules[index].ID
+	case RuleKindContinuousConditions:
+		return c.continuousConditionsConvertRules[index].ID
+	case RuleKindExclude:
+		return c.excludeRules[index].ID
+	case RuleKindConvert:
+		return c.convertRules[index].ID
+	}
+	return ""
+}
+
+// span は変換結果の1区間。
+//
+// 変換元のトークンの範囲と、その範囲をどう変換したかを保持する。
+type span struct {
+	start int // 変換元のトークンの開始位置
+	end   int // 変換元のトークンの終了位置。この位置のトークンは含まない
+
+	prefix             string // 手前に付与する「お」
+	value              string // 変換後の文字列
+	longNote           string // 後ろに付与する波線や感嘆符
+	kutenToExclamation string // 句点を変換した文字列
+
+	ruleKind  RuleKind
+	ruleIndex int
+}
+
+// newSpan は pos の位置のトークン1つ分の区間を生成する。
+func newSpan(pos int, value string, kind RuleKind, ruleIndex int) span {
+	return span{
+		start:     pos,
+		end:       pos + 1,
+		value:     value,
+		ruleKind:  kind,
+		ruleIndex: ruleIndex,
+	}
+}
+
+// String は区間の変換結果を返す。
+func (sp span) String() string {
+	return sp.prefix + sp.value + sp.longNote + sp.kutenToExclamation
+}
@@ -0,0 +1,79 @@
+package ojosama
+
+import (
+	"testing"
+
+	"github.com/jiro4989/ojosama/internal/chars"
+	"github.com/jiro4989/ojosama/internal/converter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertWithTrace(t *testing.T) {
+	assert := assert.New(t)
+
+	opt := &ConvertOption{
+		LongNote: FixedLongNote(2, 2),
+		forceCharsTestMode: &chars.TestMode{
+			Pos: 0,
+		},
+		forceKutenToExclamation: true,
+	}
+	got, traces, err := ConvertWithTrace("これはハーブです！壱百満天原サロメ。カス", opt)
+	assert.NoError(err)
+	assert.Equal("こちらはおハーブですわ～～！！壱百満天原サロメ❗カス", got)
+
+	want := []TraceSpan{
+		{TokenStart: 0, TokenEnd: 1, Text: "こちら", RuleKind: RuleKindConvert},
+		{TokenStart: 1, TokenEnd: 2, Text: "は", RuleKind: RuleKindNone, RuleIndex: -1},
+		{TokenStart: 2, TokenEnd: 3, Text: "おハーブ", RuleKind: RuleKindNone, RuleIndex: -1, Prefix: "お"},
+		{TokenStart: 3, TokenEnd: 5, Text: "ですわ～～！！", RuleKind: RuleKindConvert, LongNote: "～～！！"},
+		{TokenStart: 5, TokenEnd: 11, Text: "壱百満天原サロメ❗", RuleKind: RuleKindContinuousConditions, KutenToExclamation: "❗"},
+		{TokenStart: 11, TokenEnd: 12, Text: "カス", RuleKind: RuleKindExclude},
+	}
+	if !assert.Len(traces, len(want)) {
+		return
+	}
+	for i, w := range want {
+		tr := traces[i]
+		assert.Equal(w.TokenStart, tr.TokenStart)
+		assert.Equal(w.TokenEnd, tr.TokenEnd)
+		assert.Len(tr.Tokens, w.TokenEnd-w.TokenStart)
+		assert.Equal(w.Text, tr.Text)
+		assert.Equal(w.RuleKind, tr.RuleKind)
+		assert.Equal(w.Prefix, tr.Prefix)
+		assert.Equal(w.LongNote, tr.LongNote)
+		assert.Equal(w.KutenToExclamation, tr.KutenToExclamation)
+		if w.RuleIndex == -1 {
+			assert.Equal(-1, tr.RuleIndex)
+		}
+	}
+
+	// ルールの位置からマッチした変換ルールを特定できますわ
+	assert.Equal("こちら", converter.ConvertRules[traces[0].RuleIndex].Value)
+	assert.Equal("ですわ", converter.ConvertRules[traces[3].RuleIndex].Value)
+	assert.Equal("壱百満天原サロメ", converter.ContinuousConditionsConvertRules[traces[4].RuleIndex].Value)
+	assert.Equal("カス", converter.ExcludeRules[traces[5].RuleIndex].Conditions[0].Surface)
+	assert.Equal("ハーブ", traces[2].Tokens[0].Surface)
+	assert.Equal([]string{"名詞", "一般"}, traces[2].Tokens[0].Features[:2])
+}
+
+func TestConvertWithTraceEqualsConvert(t *testing.T) {
+	assert := assert.New(t)
+
+	seed := int64(1)
+	opt := &ConvertOption{Seed: &seed}
+	src := "ハーブです！わたしも使ってました。野球しようぜ。"
+
+	want, err := Convert(src, opt)
+	assert.NoError(err)
+
+	got, traces, err := ConvertWithTrace(src, opt)
+	assert.NoError(err)
+	assert.Equal(want, got)
+
+	var joined string
+	for _, tr := range traces {
+		joined += tr.Text
+	}
+	assert.Equal(want, joined)
+}